@@ -10,6 +10,7 @@ import (
 	"github.com/alexander-kolodka/crestic/internal/cases/handler"
 	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/restoretest"
 	"github.com/alexander-kolodka/crestic/internal/shell"
 )
 
@@ -32,6 +33,10 @@ Note: The forget step automatically runs after each backup if forget_options
 are configured in the repository. If --prune flag is set in forget_options,
 old data is actually removed from the repository to free disk space.
 
+Maintenance jobs (type check, prune and forget) run the corresponding restic
+command on each of their repositories instead. Command jobs run their shell
+commands in order, with the same hooks, healthchecks and run history.
+
 A failure in one backup job doesn't prevent other backups from completing.
 At the end, all errors are collected and returned as a combined error.
 
@@ -56,18 +61,24 @@ Examples:
 
 		jobs := filterJobs(cmd, cfg.Jobs)
 		if len(jobs) == 0 {
-			return errors.New("either --job or --all must be specified")
+			return errEmptyJobSelection
 		}
 
 		sendHealthcheck, _ := cmd.Flags().GetBool("healthcheck")
-		hc, err := newHealthChecks(cfg.HealthcheckURL, !sendHealthcheck)
+		hc, err := newHealthChecks(cfg, !sendHealthcheck)
+		if err != nil {
+			return err
+		}
+
+		history, err := newHistoryStore()
 		if err != nil {
 			return err
 		}
 
 		executor := shell.NewExecutor()
+		service := restic.NewService(executor)
 		h := handler.Chain(
-			backup.NewHandler(restic.NewService(executor), executor, hc),
+			backup.NewHandler(service, executor, hc, history, restoretest.NewTester(service)),
 			handler.WithPanicRecovery[*backup.Command](),
 		)
 
@@ -89,6 +100,8 @@ func init() {
 	_ = backupCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
 }
 
+var errEmptyJobSelection = errors.New("either --job or --all must be specified")
+
 func filterJobs(cmd *cobra.Command, backups []entity.Job) []entity.Job {
 	all, _ := cmd.Flags().GetBool("all")
 	jNames, _ := cmd.Flags().GetStringSlice("job")
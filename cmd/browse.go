@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/browse"
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "Interactively browse snapshots and restore files",
+	Long: `Browse the snapshots of a repository in an interactive terminal session.
+
+The session starts with the list of snapshots, newest first. Open a snapshot
+by its number to walk its directories (restic ls --json), show file details,
+mark files and directories, and restore the marked entries to a target
+directory. The repository credentials are taken from the configuration.
+
+Type ? in the session for the list of commands.
+
+Examples:
+  # Browse snapshots of a repository
+  crestic browse --repo local-backup
+
+A typical session:
+  snapshots> 1                  # open the newest snapshot
+  4f2a9c1e:/home/user> 3        # enter directory 3
+  4f2a9c1e:/home/user/docs> m 2 5
+  4f2a9c1e:/home/user/docs> r ./restore
+  4f2a9c1e:/home/user/docs> q`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		repo, _ := cmd.Flags().GetString("repo")
+		err = validateGivenRepoNames(cfg, []string{repo})
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			browse.NewHandler(restic.NewService(executor)),
+			handler.WithPanicRecovery[*browse.Command](),
+		)
+
+		return h.Handle(cmd.Context(), &browse.Command{
+			Repo: cfg.Repositories[repo],
+			In:   os.Stdin,
+			Out:  os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+	browseCmd.Flags().StringP("repo", "r", "", "Repository to browse")
+	_ = browseCmd.MarkFlagRequired("repo")
+
+	_ = browseCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+}
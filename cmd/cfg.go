@@ -10,6 +10,7 @@ import (
 
 	"github.com/alexander-kolodka/crestic/internal/dto"
 	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/redact"
 )
 
 // findConfigFile searches for config file in priority order:
@@ -74,5 +75,7 @@ func loadConfig(cfgPath string) (*entity.Config, error) {
 		return nil, err
 	}
 
+	redact.AddSecrets(entityCfg.Secrets()...)
+
 	return entityCfg, nil
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/alexander-kolodka/crestic/internal/cases/check"
@@ -24,6 +26,11 @@ For each repository, the command:
   3. Verifies repository integrity if it does exist
   4. Reports any errors or issues found
 
+All selected repositories are processed even if some of them fail. When more than
+one repository is involved, a summary is printed at the end, and the command exits
+with a non-zero status if any repository failed. With --healthcheck the result is
+reported to Healthchecks.io, like for 'crestic backup'.
+
 Examples:
   # Check all repositories
   crestic check --all
@@ -45,14 +52,20 @@ Examples:
 			return err
 		}
 
+		hc, err := healthChecksFromFlags(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
 		executor := shell.NewExecutor()
 		h := handler.Chain(
-			check.NewHandler(restic.NewService(executor)),
+			check.NewHandler(restic.NewService(executor), hc),
 			handler.WithPanicRecovery[*check.Command](),
 		)
 
 		return h.Handle(cmd.Context(), &check.Command{
 			Repos: repos,
+			Out:   os.Stdout,
 		})
 	},
 }
@@ -61,4 +74,5 @@ func init() {
 	rootCmd.AddCommand(checkCmd)
 	checkCmd.Flags().StringSliceP("repo", "r", nil, "Check specific repository/repositories (can specify multiple)")
 	checkCmd.Flags().BoolP("all", "a", false, "Check all repositories")
+	addHealthcheckFlags(checkCmd)
 }
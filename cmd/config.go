@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/validate"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration",
+	Long: `Validate the configuration file and the repositories of copy jobs.
+
+The config file is loaded and checked the same way as by every other command.
+Then, for every copy job, the chunker parameters of the source and target
+repositories are compared. Copying between repositories with different chunker
+parameters works, but the copied data is not deduplicated against the data
+already in the target. Mismatches are reported as warnings.
+
+A missing copy target is created with the chunker parameters of its source
+when the copy job runs.
+
+Examples:
+  # Validate the config and check copy jobs
+  crestic config validate
+
+  # Only validate the config file, without accessing repositories
+  crestic config validate --offline`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		offline, _ := cmd.Flags().GetBool("offline")
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			validate.NewHandler(restic.NewService(executor)),
+			handler.WithPanicRecovery[*validate.Command](),
+		)
+
+		return h.Handle(cmd.Context(), &validate.Command{
+			Jobs:    cfg.Jobs,
+			Offline: offline,
+			Out:     os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configValidateCmd.Flags().Bool("offline", false, "Only validate the config file, without accessing repositories")
+}
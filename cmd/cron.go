@@ -11,6 +11,7 @@ import (
 	"github.com/alexander-kolodka/crestic/internal/cases/handler"
 	"github.com/alexander-kolodka/crestic/internal/cron"
 	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/restoretest"
 	"github.com/alexander-kolodka/crestic/internal/shell"
 )
 
@@ -59,14 +60,20 @@ cron expression matches.`,
 		}
 
 		sendHealthcheck, _ := cmd.Flags().GetBool("healthcheck")
-		hc, err := newHealthChecks(cfg.HealthcheckURL, !sendHealthcheck)
+		hc, err := newHealthChecks(cfg, !sendHealthcheck)
+		if err != nil {
+			return err
+		}
+
+		history, err := newHistoryStore()
 		if err != nil {
 			return err
 		}
 
 		executor := shell.NewExecutor()
+		service := restic.NewService(executor)
 		h := handler.Chain(
-			backup.NewHandler(restic.NewService(executor), executor, hc),
+			backup.NewHandler(service, executor, hc, history, restoretest.NewTester(service)),
 			handler.WithPanicRecovery[*backup.Command](),
 			handler.WithLock[*backup.Command](fmt.Sprintf("crestic-cron-%s.lock", fileName)),
 		)
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/diff"
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var diffCmd = &cobra.Command{
+	Use:         "diff",
+	Short:       "Show what changed between two snapshots of a job",
+	Annotations: dataOutput,
+	Long: `Compare two snapshots of a job (restic diff --json) and print a summary
+of added, removed and modified files with the amount of data added and removed.
+
+Snapshots are selected among the job's snapshots (see 'crestic snapshots --job').
+By default the latest snapshot is compared with the one before it, i.e. the
+command shows what changed in the last backup.
+
+--from and --to accept a snapshot ID or a number of snapshots before the latest
+one: 0 is the latest snapshot, 1 the one before it, and so on. If only --to is
+given, it is compared with the snapshot before it.
+
+In success hooks, CRESTIC_JOB_NAME and CRESTIC_SNAPSHOT_ID identify the snapshot
+that has just been created.
+
+Examples:
+  # What changed in the last backup
+  crestic diff --job documents
+
+  # Changes over the last 7 backups, with the list of changed files
+  crestic diff --job documents --from 7 --files
+
+  # Compare two specific snapshots of a repository
+  crestic diff --repo local-backup --from 4f2a9c1e --to 9d81b7aa
+
+  # In a success hook
+  crestic diff --job "$CRESTIC_JOB_NAME" --to "$CRESTIC_SNAPSHOT_ID" --json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		query, err := diffQuery(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			diff.NewHandler(restic.NewService(executor)),
+			handler.WithPanicRecovery[*diff.Command](),
+		)
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		files, _ := cmd.Flags().GetBool("files")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		return h.Handle(cmd.Context(), &diff.Command{
+			Query: query,
+			From:  from,
+			To:    to,
+			Files: files,
+			JSON:  asJSON,
+			Out:   os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringP("job", "j", "", "Compare snapshots created by a job")
+	diffCmd.Flags().StringP("repo", "r", "", "Compare snapshots of a repository")
+	diffCmd.Flags().String("from", "", "Snapshot ID or number of snapshots before the latest (default: the one before --to)")
+	diffCmd.Flags().String("to", "", "Snapshot ID or number of snapshots before the latest (default: latest)")
+	diffCmd.Flags().Bool("files", false, "List changed files")
+	diffCmd.Flags().String("host", "", "Only consider snapshots created on this host")
+
+	diffCmd.MarkFlagsMutuallyExclusive("job", "repo")
+	diffCmd.MarkFlagsOneRequired("job", "repo")
+
+	_ = diffCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+	_ = diffCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+}
+
+func diffQuery(cmd *cobra.Command, cfg *entity.Config) (entity.SnapshotQuery, error) {
+	host, _ := cmd.Flags().GetString("host")
+
+	jobName, _ := cmd.Flags().GetString("job")
+	if jobName == "" {
+		repo, _ := cmd.Flags().GetString("repo")
+		err := validateGivenRepoNames(cfg, []string{repo})
+		if err != nil {
+			return entity.SnapshotQuery{}, err
+		}
+
+		return entity.SnapshotQuery{
+			Repo:   cfg.Repositories[repo],
+			Filter: entity.SnapshotFilter{Host: host},
+		}, nil
+	}
+
+	q, err := jobQuery(cfg, jobName)
+	if err != nil {
+		return entity.SnapshotQuery{}, err
+	}
+
+	if host != "" {
+		q.Filter.Host = host
+	}
+
+	return q, nil
+}
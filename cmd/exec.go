@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"errors"
+	"os"
 
 	"github.com/spf13/cobra"
 
@@ -59,6 +60,16 @@ Examples:
   # Mount repository (requires FUSE)
   crestic exec --repo local-backup mount /mnt/backup
 
+  # List snapshots of all repositories at once, continuing past failures
+  crestic exec --all --parallel 0 --keep-going snapshots
+
+Repositories are processed one after another and execution stops at the first
+failure. With --keep-going the remaining repositories are still processed.
+With --parallel N the command runs in N repositories at once (all of them with
+--parallel 0); the output of each repository is printed as a whole
+when it finishes, so it doesn't interleave. When more than one repository is
+involved, a summary with the exit code and duration of each is printed at the end.
+
 For more information about restic commands, see:
   https://restic.readthedocs.io/`,
 	DisableFlagParsing: false,
@@ -84,10 +95,22 @@ For more information about restic commands, see:
 			handler.WithPanicRecovery[*exec.Command](),
 		)
 
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		if parallel < 0 {
+			return errors.New("--parallel must not be negative")
+		}
+		if parallel == 0 {
+			parallel = len(repos)
+		}
+
+		keepGoing, _ := cmd.Flags().GetBool("keep-going")
 		return h.Handle(cmd.Context(), &exec.Command{
-			Repos: repos,
-			Cmd:   args[0],
-			Args:  args[1:],
+			Repos:     repos,
+			Cmd:       args[0],
+			Args:      args[1:],
+			Parallel:  parallel,
+			KeepGoing: keepGoing,
+			Out:       os.Stdout,
 		})
 	},
 }
@@ -97,6 +120,8 @@ func init() {
 	execCmd.Flags().
 		StringSliceP("repo", "r", nil, "Repository/repositories to execute command on (can specify multiple)")
 	execCmd.Flags().BoolP("all", "a", false, "Execute on all repositories")
+	execCmd.Flags().Int("parallel", 1, "Number of repositories to process at once (0: all)")
+	execCmd.Flags().Bool("keep-going", false, "Continue with other repositories after a failure")
 
 	_ = execCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
 }
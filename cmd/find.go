@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/find"
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var findCmd = &cobra.Command{
+	Use:         "find <pattern>...",
+	Short:       "Find files across repositories",
+	Annotations: dataOutput,
+	Long: `Search snapshots of several repositories for files and directories
+matching the given patterns (restic find --json) and print a merged timeline.
+
+All repositories are searched concurrently. Identical versions of a file,
+i.e. the same path, size and modification time, are merged: for every version
+the output shows in which repositories it exists, in how many snapshots,
+and when it was first and last seen.
+
+Patterns are restic find patterns: a plain name matches the file name,
+a pattern containing a slash matches the path, and * and ? are wildcards.
+
+With --job, only snapshots created by the job are searched (see 'crestic snapshots').
+
+Examples:
+  # Find a file in all repositories
+  crestic find --all invoice-2024.pdf
+
+  # Find files of a job by pattern
+  crestic find --job documents '*.kdbx'
+
+  # Search specific repositories, output as JSON
+  crestic find --repo local,remote '/home/user/.ssh/*' --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		queries, err := snapshotQueries(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			find.NewHandler(restic.NewService(executor)),
+			handler.WithPanicRecovery[*find.Command](),
+		)
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+		return h.Handle(cmd.Context(), &find.Command{
+			Queries:  queries,
+			Patterns: args,
+			JSON:     asJSON,
+			Out:      os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(findCmd)
+	findCmd.Flags().StringSliceP("job", "j", nil, "Search only snapshots created by specific jobs (comma-separated)")
+	findCmd.Flags().StringSliceP("repo", "r", nil, "Search all snapshots of specific repositories")
+	findCmd.Flags().BoolP("all", "a", false, "Search all snapshots of all repositories")
+	findCmd.Flags().String("host", "", "Only search snapshots created on this host")
+
+	_ = findCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+	_ = findCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+}
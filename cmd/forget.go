@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/alexander-kolodka/crestic/internal/cases/forget"
@@ -32,6 +34,11 @@ Retention policy example:
 The command will keep the specified number of snapshots for each time period
 and mark older ones for deletion.
 
+All selected repositories are processed even if some of them fail. When more than
+one repository is involved, a summary is printed at the end, and the command exits
+with a non-zero status if any repository failed. With --healthcheck the result is
+reported to Healthchecks.io, like for 'crestic backup'.
+
 Examples:
   # Show what would be deleted (safe, no changes)
   crestic forget --all --dry-run
@@ -56,9 +63,14 @@ Examples:
 			return err
 		}
 
+		hc, err := healthChecksFromFlags(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
 		executor := shell.NewExecutor()
 		h := handler.Chain(
-			forget.NewHandler(restic.NewService(executor)),
+			forget.NewHandler(restic.NewService(executor), hc),
 			handler.WithPanicRecovery[*forget.Command](),
 		)
 
@@ -68,6 +80,7 @@ Examples:
 			Repos:  repos,
 			Prune:  prune,
 			DryRun: dryRun,
+			Out:    os.Stdout,
 		})
 	},
 }
@@ -76,6 +89,7 @@ func init() {
 	rootCmd.AddCommand(forgetCmd)
 	forgetCmd.Flags().StringSliceP("repo", "r", nil, "Run forget for a specific repository")
 	forgetCmd.Flags().BoolP("all", "a", false, "Run forget for all jobs")
+	addHealthcheckFlags(forgetCmd)
 	forgetCmd.Flags().Bool("dry-run", false, "Show what would be deleted without actually deleting")
 	forgetCmd.Flags().Bool("prune", false, "Actually remove the data (frees up space)")
 
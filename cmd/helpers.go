@@ -3,7 +3,11 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
@@ -11,8 +15,14 @@ import (
 	"github.com/alexander-kolodka/crestic/internal/cases/backup"
 	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/runhistory"
 )
 
+// isTerminal reports whether f is a terminal, e.g. to print colors only when stdout isn't piped.
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
 // getRepos returns the list of repositories to operate on based on command flags.
 // It checks for either --repo flag (specific repositories) or --all flag (all repositories).
 // Returns an error if neither flag is specified or if specified repository names are invalid.
@@ -32,6 +42,10 @@ func getRepos(cmd *cobra.Command, cfg *entity.Config) ([]*entity.Repository, err
 		return nil, errors.New("either --repo or --all must be specified")
 	}
 
+	slices.SortFunc(repos, func(a, b *entity.Repository) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
 	return repos, nil
 }
 
@@ -85,11 +99,86 @@ func repoAutocompletion(cmd *cobra.Command, _ []string, _ string) ([]string, cob
 	return lo.Keys(cfg.Repositories), cobra.ShellCompDirectiveNoFileComp
 }
 
+// addHealthcheckFlags registers the --healthcheck and --healthcheck-url flags.
+func addHealthcheckFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("healthcheck", false, "Send healthcheck notifications")
+	cmd.Flags().String("healthcheck-url", "", "Healthcheck URL to notify instead of the configured one")
+}
+
+// healthChecksFromFlags returns the healthcheck client selected by the flags of addHealthcheckFlags.
+// --healthcheck-url replaces the configured URL.
+//
+//nolint:ireturn // can't return struct
+func healthChecksFromFlags(cmd *cobra.Command, cfg *entity.Config) (backup.HealthChecks, error) {
+	hcURL, _ := cmd.Flags().GetString("healthcheck-url")
+	if hcURL != "" {
+		cfg.HealthcheckURL = hcURL
+	}
+
+	sendHealthcheck, _ := cmd.Flags().GetBool("healthcheck")
+	return newHealthChecks(cfg, !sendHealthcheck)
+}
+
 //nolint:ireturn // can't return struct
-func newHealthChecks(healthcheckURL string, dummy bool) (backup.HealthChecks, error) {
-	if dummy || healthcheckURL == "" {
+func newHealthChecks(cfg *entity.Config, dummy bool) (backup.HealthChecks, error) {
+	if dummy || cfg.HealthcheckURL == "" {
 		return &healthchecks.Dummy{}, nil
 	}
 
-	return healthchecks.NewClient(healthcheckURL)
+	return healthchecks.NewClient(cfg.HealthcheckURL, cfg.Healthcheck)
+}
+
+func newHistoryStore() (*runhistory.Store, error) {
+	path, err := runhistory.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return runhistory.NewStore(path), nil
+}
+
+// getJobs returns the jobs with the given names in the given order.
+// Returns an error if any job name is not found in the config.
+func getJobs(cfg *entity.Config, jobNames []string) ([]entity.Job, error) {
+	jobs := make([]entity.Job, 0, len(jobNames))
+	for _, name := range jobNames {
+		j, ok := lo.Find(cfg.Jobs, func(j entity.Job) bool {
+			return j.GetName() == name
+		})
+		if !ok {
+			return nil, fmt.Errorf("invalid job name: %s", name)
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+// jobQuery returns the snapshot query of a single job. Jobs with sources write a snapshot
+// per database, so one of their databases is selected with "<job>/<database>".
+func jobQuery(cfg *entity.Config, name string) (entity.SnapshotQuery, error) {
+	for _, j := range cfg.Jobs {
+		queries, ok := entity.SnapshotQueries(j)
+		if j.GetName() == name {
+			if !ok {
+				return entity.SnapshotQuery{}, fmt.Errorf("job %s doesn't create snapshots", name)
+			}
+			if len(queries) > 1 {
+				return entity.SnapshotQuery{}, fmt.Errorf(
+					"job %s writes a snapshot per database, select one of: %s",
+					name,
+					strings.Join(lo.Map(queries, func(q entity.SnapshotQuery, _ int) string { return q.Job }), ", "),
+				)
+			}
+			return queries[0], nil
+		}
+
+		q, found := lo.Find(queries, func(q entity.SnapshotQuery) bool { return q.Job == name })
+		if found {
+			return q, nil
+		}
+	}
+
+	return entity.SnapshotQuery{}, fmt.Errorf("invalid job name: %s", name)
 }
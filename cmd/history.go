@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/history"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+var historyCmd = &cobra.Command{
+	Use:         "history",
+	Short:       "Show past backup and copy runs",
+	Annotations: dataOutput,
+	Long: `Show the local history of backup and copy runs.
+
+Every run of 'crestic backup' and 'crestic cron' is recorded in
+~/.crestic/history.db together with its jobs: start and end time, status,
+error, snapshot ID and restic backup statistics. Dry runs are not recorded.
+
+The history is stored locally, so it can be queried without access to
+the repositories.
+
+Examples:
+  # Show all recorded runs
+  crestic history
+
+  # Show runs of a single job
+  crestic history --job documents
+
+  # Show runs from the last 7 days
+  crestic history --since 7d
+
+  # Output as JSON
+  crestic history --job documents --since 30d --json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		job, _ := cmd.Flags().GetString("job")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		var since time.Time
+		sinceStr, _ := cmd.Flags().GetString("since")
+		if sinceStr != "" {
+			d, err := entity.ParseDuration(sinceStr)
+			if err != nil {
+				return err
+			}
+			since = time.Now().Add(-d)
+		}
+
+		store, err := newHistoryStore()
+		if err != nil {
+			return err
+		}
+
+		h := handler.Chain(
+			history.NewHandler(store),
+			handler.WithPanicRecovery[*history.Command](),
+		)
+
+		return h.Handle(cmd.Context(), &history.Command{
+			Job:   job,
+			Since: since,
+			JSON:  asJSON,
+			Out:   os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().StringP("job", "j", "", "Show only runs of a specific job")
+	historyCmd.Flags().String("since", "", "Show only runs started within this period (e.g. 12h, 7d, 2w)")
+
+	_ = historyCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+}
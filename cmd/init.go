@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/initrepo"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Initialize repositories",
+	Long: `Create repositories that don't exist yet, using their init_options.
+
+Repositories that are already initialized are left untouched. This command
+works regardless of the auto_init setting, and is the way to create
+repositories that have auto_init disabled.
+
+With init_options.copy_chunker_params_from, the new repository gets the chunker
+parameters of another repository, so snapshots copied between them are
+deduplicated. If both repositories are selected, the source is created first.
+
+Examples:
+  # Initialize a specific repository
+  crestic init --repo usb-backup
+
+  # Initialize all missing repositories
+  crestic init --all`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		repos, err := getRepos(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			initrepo.NewHandler(restic.NewService(executor), &healthchecks.Dummy{}),
+			handler.WithPanicRecovery[*initrepo.Command](),
+		)
+
+		return h.Handle(cmd.Context(), &initrepo.Command{
+			Repos: repos,
+			Out:   os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringSliceP("repo", "r", nil, "Initialize specific repository/repositories (can specify multiple)")
+	initCmd.Flags().BoolP("all", "a", false, "Initialize all missing repositories")
+
+	_ = initCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+}
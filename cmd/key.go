@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/key"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage repository keys",
+	Long: `List, add and rotate the keys (passwords) of a repository.
+
+The repository is opened with its configured password source. The password of
+a new key is printed by the command given with --new-password-command, which
+is run once by crestic and passed to restic through a temporary file.
+
+A new key is always verified to open the repository before anything else
+happens. Afterwards the config change needed to use it is printed.`,
+}
+
+var keyListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List the keys of a repository",
+	Annotations: dataOutput,
+	Long: `List the keys of a repository. The key opened by the configured password
+is marked as current.
+
+Examples:
+  crestic key list --repo local-backup
+
+  # Output as JSON
+  crestic key list --repo local-backup --json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runKey(cmd, key.ActionList)
+	},
+}
+
+var keyAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a key to a repository",
+	Long: `Add a key for a new password to a repository. Existing keys remain valid,
+e.g. to give another machine its own password.
+
+Examples:
+  crestic key add --repo local-backup --new-password-command "pass show restic/laptop"`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runKey(cmd, key.ActionAdd)
+	},
+}
+
+var keyRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replace the current key of a repository",
+	Long: `Replace the key of the configured password with a key for a new password.
+
+The new key is added and verified to open the repository first. Only then the
+old key is removed, so the repository stays accessible if anything fails.
+You will be asked for confirmation unless --yes is given.
+
+Examples:
+  crestic key rotate --repo local-backup --new-password-command "pass show restic/local-2025"`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runKey(cmd, key.ActionRotate)
+	},
+}
+
+func runKey(cmd *cobra.Command, action key.Action) error {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfig(cfgPath)
+	if err != nil {
+		return err
+	}
+
+	repoName, _ := cmd.Flags().GetString("repo")
+	repo, ok := cfg.Repositories[repoName]
+	if !ok {
+		return fmt.Errorf("invalid repository name: %s", repoName)
+	}
+
+	newPasswordCMD, _ := cmd.Flags().GetString("new-password-command")
+	yes, _ := cmd.Flags().GetBool("yes")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	executor := shell.NewExecutor()
+	h := handler.Chain(
+		key.NewHandler(restic.NewService(executor), newStdinConfirmer(yes)),
+		handler.WithPanicRecovery[*key.Command](),
+	)
+
+	return h.Handle(cmd.Context(), &key.Command{
+		Action:         action,
+		Repo:           repo,
+		NewPasswordCMD: newPasswordCMD,
+		JSON:           asJSON,
+		Out:            os.Stdout,
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(keyCmd)
+	keyCmd.AddCommand(keyListCmd, keyAddCmd, keyRotateCmd)
+
+	keyCmd.PersistentFlags().StringP("repo", "r", "", "Repository to manage keys of")
+	_ = keyCmd.MarkPersistentFlagRequired("repo")
+	_ = keyCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+
+	for _, c := range []*cobra.Command{keyAddCmd, keyRotateCmd} {
+		c.Flags().String("new-password-command", "", "Command printing the password of the new key")
+		_ = c.MarkFlagRequired("new-password-command")
+	}
+	keyRotateCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation before removing the old key")
+}
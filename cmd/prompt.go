@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdinConfirmer asks yes/no questions on the terminal.
+// With assumeYes set, every question is answered "yes" without prompting.
+type stdinConfirmer struct {
+	assumeYes bool
+	in        io.Reader
+	out       io.Writer
+}
+
+func newStdinConfirmer(assumeYes bool) *stdinConfirmer {
+	return &stdinConfirmer{
+		assumeYes: assumeYes,
+		in:        os.Stdin,
+		out:       os.Stderr,
+	}
+}
+
+func (c *stdinConfirmer) Confirm(prompt string) (bool, error) {
+	if c.assumeYes {
+		return true, nil
+	}
+
+	_, _ = fmt.Fprintf(c.out, "%s [y/N]: ", prompt)
+
+	answer, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("read answer (use --yes for non-interactive runs): %w", err)
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdinConfirmerAssumeYes(t *testing.T) {
+	var out bytes.Buffer
+	c := &stdinConfirmer{assumeYes: true, in: strings.NewReader(""), out: &out}
+
+	ok, err := c.Confirm("Restore in place?")
+	require.NoError(t, err)
+
+	assert.True(t, ok)
+	assert.Empty(t, out.String(), "the question isn't asked")
+}
+
+func TestStdinConfirmer(t *testing.T) {
+	for answer, expected := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false} {
+		var out bytes.Buffer
+		c := &stdinConfirmer{in: strings.NewReader(answer), out: &out}
+
+		ok, err := c.Confirm("Restore in place?")
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, ok, "answer %q", answer)
+		assert.Equal(t, "Restore in place? [y/N]: ", out.String())
+	}
+
+	_, err := (&stdinConfirmer{in: strings.NewReader(""), out: &bytes.Buffer{}}).Confirm("Restore in place?")
+	require.ErrorContains(t, err, "use --yes for non-interactive runs")
+}
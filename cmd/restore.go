@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+
 	"github.com/spf13/cobra"
 
 	"github.com/alexander-kolodka/crestic/internal/cases/handler"
 	"github.com/alexander-kolodka/crestic/internal/cases/restore"
+	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/restic"
 	"github.com/alexander-kolodka/crestic/internal/shell"
 )
@@ -18,6 +24,14 @@ This command extracts files from a backup snapshot to a target directory.
 You can restore the entire snapshot or specific files/directories. By default,
 the latest snapshot is restored.
 
+With --job, the repository is taken from the job and the latest snapshot
+matching the job's paths and tags is restored. Use --path to restore only a
+part of it: relative paths are resolved against each of the job's source
+directories, absolute paths are used as is.
+
+With --in-place, files are restored to their original locations. You will be
+asked for confirmation first unless --yes or --dry-run is given.
+
 Examples:
   # Restore latest snapshot to a directory
   crestic restore --repo local-backup --target ./restore
@@ -25,8 +39,17 @@ Examples:
   # Restore specific snapshot by ID
   crestic restore --repo local-backup --snapshot abc123 --target ./restore
 
+  # Restore the latest snapshot of a job to a directory
+  crestic restore --job documents --target ./restore
+
+  # Put a single directory of a job back where it was, keeping newer files
+  crestic restore --job documents --path taxes/2024 --in-place --overwrite if-newer
+
+  # Show what an in-place restore would do
+  crestic restore --job documents --in-place --dry-run
+
 First list snapshots to see what's available:
-  crestic exec --repo local-backup snapshots`,
+  crestic snapshots --repo local-backup`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		cfgPath, _ := cmd.Flags().GetString("config")
 		cfg, err := loadConfig(cfgPath)
@@ -34,38 +57,121 @@ First list snapshots to see what's available:
 			return err
 		}
 
-		repo, _ := cmd.Flags().GetString("repo")
-		err = validateGivenRepoNames(cfg, []string{repo})
+		c, err := restoreCommand(cmd, cfg)
 		if err != nil {
 			return err
 		}
 
-		target, _ := cmd.Flags().GetString("target")
-		snapshot, _ := cmd.Flags().GetString("snapshot")
-		if snapshot == "" {
-			snapshot = "latest"
-		}
-
+		yes, _ := cmd.Flags().GetBool("yes")
 		executor := shell.NewExecutor()
 		h := handler.Chain(
-			restore.NewHandler(restic.NewService(executor)),
+			restore.NewHandler(restic.NewService(executor), newStdinConfirmer(yes)),
 			handler.WithPanicRecovery[*restore.Command](),
 		)
 
-		return h.Handle(cmd.Context(), &restore.Command{
-			Repo:     cfg.Repositories[repo],
-			Target:   target,
-			Snapshot: snapshot,
-		})
+		return h.Handle(cmd.Context(), c)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(restoreCmd)
-	restoreCmd.Flags().StringP("repo", "r", "", "Restore specific repository (required)")
-	restoreCmd.Flags().StringP("target", "t", "", "Directory to restore to (required)")
-	restoreCmd.Flags().StringP("snapshot", "s", "", "snapshot")
-	_ = restoreCmd.MarkFlagRequired("target")
+	restoreCmd.Flags().StringP("repo", "r", "", "Restore from specific repository")
+	restoreCmd.Flags().StringP("job", "j", "", "Restore the latest snapshot of a job")
+	restoreCmd.Flags().StringP("target", "t", "", "Directory to restore to")
+	restoreCmd.Flags().Bool("in-place", false, "Restore files to their original locations")
+	restoreCmd.Flags().StringP("snapshot", "s", "", "Snapshot ID (default: latest)")
+	restoreCmd.Flags().StringSlice("path", nil, "Restore only these paths of the snapshot")
+	restoreCmd.Flags().StringSlice("include", nil, "Restore only files matching these patterns")
+	restoreCmd.Flags().StringSlice("exclude", nil, "Skip files matching these patterns")
+	restoreCmd.Flags().String("overwrite", "", "Overwrite existing files: always, if-changed, if-newer or never")
+	restoreCmd.Flags().Bool("dry-run", false, "Show what would be restored without writing files")
+	restoreCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation before in-place restore")
+
+	restoreCmd.MarkFlagsMutuallyExclusive("repo", "job")
+	restoreCmd.MarkFlagsOneRequired("repo", "job")
+	restoreCmd.MarkFlagsMutuallyExclusive("target", "in-place")
+	restoreCmd.MarkFlagsOneRequired("target", "in-place")
 
 	_ = restoreCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+	_ = restoreCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+	_ = restoreCmd.RegisterFlagCompletionFunc(
+		"overwrite",
+		func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return overwriteModes, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+}
+
+var overwriteModes = []string{"always", "if-changed", "if-newer", "never"}
+
+func restoreCommand(cmd *cobra.Command, cfg *entity.Config) (*restore.Command, error) {
+	target, _ := cmd.Flags().GetString("target")
+	inPlace, _ := cmd.Flags().GetBool("in-place")
+	snapshot, _ := cmd.Flags().GetString("snapshot")
+	if snapshot == "" {
+		snapshot = "latest"
+	}
+
+	overwrite, _ := cmd.Flags().GetString("overwrite")
+	if overwrite != "" && !slices.Contains(overwriteModes, overwrite) {
+		return nil, fmt.Errorf("invalid --overwrite value %q: must be one of %v", overwrite, overwriteModes)
+	}
+
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	paths, _ := cmd.Flags().GetStringSlice("path")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	c := &restore.Command{
+		Snapshot:  snapshot,
+		Target:    target,
+		InPlace:   inPlace,
+		Exclude:   exclude,
+		Overwrite: overwrite,
+		DryRun:    dryRun,
+	}
+
+	jobName, _ := cmd.Flags().GetString("job")
+	if jobName == "" {
+		repo, _ := cmd.Flags().GetString("repo")
+		err := validateGivenRepoNames(cfg, []string{repo})
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.ContainsFunc(paths, func(p string) bool { return !path.IsAbs(p) }) {
+			return nil, errors.New("--path must be absolute when restoring from --repo")
+		}
+
+		c.Repo = cfg.Repositories[repo]
+		c.Include = append(include, paths...)
+		return c, nil
+	}
+
+	q, err := jobQuery(cfg, jobName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Repo = q.Repo
+	c.Filter = q.Filter
+	c.Include = append(include, jobPaths(q.Filter.Paths, paths)...)
+	return c, nil
+}
+
+// jobPaths resolves relative paths against each of the job's source directories.
+func jobPaths(sources, paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		if path.IsAbs(p) || len(sources) == 0 {
+			result = append(result, p)
+			continue
+		}
+
+		for _, src := range sources {
+			result = append(result, path.Join(src, p))
+		}
+	}
+
+	return result
 }
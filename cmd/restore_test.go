@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []string
+		paths    []string
+		expected []string
+	}{
+		{
+			name:     "relative path is resolved against every source",
+			sources:  []string{"/home/docs", "/home/photos"},
+			paths:    []string{"2024"},
+			expected: []string{"/home/docs/2024", "/home/photos/2024"},
+		},
+		{
+			name:     "absolute path is used as is",
+			sources:  []string{"/home/docs"},
+			paths:    []string{"/home/docs/taxes"},
+			expected: []string{"/home/docs/taxes"},
+		},
+		{
+			name:     "relative path is kept without sources",
+			paths:    []string{"taxes"},
+			expected: []string{"taxes"},
+		},
+		{
+			name:    "no paths",
+			sources: []string{"/home/docs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, jobPaths(tt.sources, tt.paths))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/restoretest"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	tester "github.com/alexander-kolodka/crestic/internal/restoretest"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var restoreTestCmd = &cobra.Command{
+	Use:   "restore-test",
+	Short: "Verify that backups can be restored",
+	Long: `Restore files of the latest snapshot of each job and verify their content.
+
+For each backup job with restore_test configured, the command restores the
+configured paths and a random sample of other files from the job's latest
+snapshot into a temporary directory. The SHA-256 checksum of every restored
+file is compared with the live source file or with a recorded manifest.
+The temporary directory is removed afterwards.
+
+When comparing with the source, files modified since the snapshot was taken
+are skipped. The test fails if any restored file differs, if the restore
+itself fails or if no file could be verified at all.
+
+The command exits with a non-zero status if any test fails. With --healthcheck
+the result is also reported to Healthchecks.io.
+
+Examples:
+  # Run restore tests of all jobs with restore_test
+  crestic restore-test --all
+
+  # Verify 50 random files of a job, even without restore_test in config
+  crestic restore-test --job documents --sample 50
+
+  # Report to a dedicated check
+  crestic restore-test --all --healthcheck \
+    --healthcheck-url https://hc-ping.com/your-restore-test-uuid`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		jobs := filterJobs(cmd, cfg.Jobs)
+		if len(jobs) == 0 {
+			return errEmptyJobSelection
+		}
+
+		hc, err := healthChecksFromFlags(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			restoretest.NewHandler(tester.NewTester(restic.NewService(executor)), hc),
+			handler.WithPanicRecovery[*restoretest.Command](),
+		)
+
+		sample, _ := cmd.Flags().GetInt("sample")
+		return h.Handle(cmd.Context(), &restoretest.Command{
+			Jobs:   jobs,
+			Sample: sample,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreTestCmd)
+	restoreTestCmd.Flags().BoolP("all", "a", false, "Test all jobs with restore_test")
+	restoreTestCmd.Flags().StringSliceP("job", "j", nil, "Test only specific jobs by name (comma-separated)")
+	restoreTestCmd.Flags().Int("sample", 0, "Number of random files to verify (overrides restore_test.sample)")
+	addHealthcheckFlags(restoreTestCmd)
+
+	_ = restoreTestCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+}
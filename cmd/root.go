@@ -3,6 +3,10 @@ package cmd
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -34,7 +38,7 @@ Features:
 		}
 
 		logLevel, _ := cmd.Flags().GetString("log-level")
-		ctx := logger.New(logFormat(ci, json), toZerologLevel(logLevel)).
+		ctx := logger.New(logOutput(cmd), logFormat(ci, json), toZerologLevel(logLevel)).
 			WithContext(cmd.Context())
 		ctx = logger.WithSource(ctx, "crestic")
 		if json {
@@ -48,16 +52,51 @@ Features:
 			ctx = shell.WithPrintingCommands(ctx)
 		}
 
+		grace, _ := cmd.Flags().GetDuration("grace-period")
+		if grace <= 0 {
+			return errors.New("--grace-period must be positive")
+		}
+		gracePeriod.Store(int64(grace))
+		ctx = shell.WithGracePeriod(ctx, grace)
+
 		cmd.SetContext(ctx)
 
 		return nil
 	},
 }
 
+// annotationDataOutput marks commands printing data to stdout, e.g. as JSON with --json.
+// Their logs go to stderr, so the output can be piped to other tools.
+const annotationDataOutput = "crestic/data-output"
+
+// dataOutput is the annotation of commands printing data to stdout.
+var dataOutput = map[string]string{annotationDataOutput: "true"}
+
+// logOutput returns where the logs of cmd are written.
+func logOutput(cmd *cobra.Command) io.Writer {
+	if cmd.Annotations[annotationDataOutput] != "" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// gracePeriod is the value of the --grace-period flag, read by main on shutdown.
+var gracePeriod atomic.Int64
+
 func Execute(ctx context.Context) error {
 	return rootCmd.ExecuteContext(ctx)
 }
 
+// GracePeriod returns the time running commands get to finish after SIGINT/SIGTERM
+// before they're killed.
+func GracePeriod() time.Duration {
+	d := time.Duration(gracePeriod.Load())
+	if d <= 0 {
+		return shell.DefaultGracePeriod
+	}
+	return d
+}
+
 func init() {
 	rootCmd.SetVersionTemplate("crestic version {{.Version}}\n")
 	rootCmd.PersistentFlags().
@@ -67,6 +106,8 @@ func init() {
 	rootCmd.PersistentFlags().Bool("ci", false, "output logs as plain text without colors (for CI/pipelines)")
 	rootCmd.PersistentFlags().Bool("json", false, "output logs in JSON format")
 	rootCmd.PersistentFlags().Bool("print-commands", false, "Print executed shell commands")
+	rootCmd.PersistentFlags().Duration("grace-period", shell.DefaultGracePeriod,
+		"time running commands get to finish after SIGINT/SIGTERM before they're killed")
 
 	rootCmd.SilenceUsage = true
 
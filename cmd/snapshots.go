@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/snapshots"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var snapshotsCmd = &cobra.Command{
+	Use:         "snapshots",
+	Short:       "List snapshots of jobs or repositories",
+	Annotations: dataOutput,
+	Long: `List snapshots across one or more repositories, merged and sorted by time.
+
+With --job, the job's repository, source paths and tags are turned into the
+matching restic --path/--tag filters (and --host, if the job sets the host option),
+so only snapshots created by that job are listed. For copy jobs the filters come
+from the job's path, tag and host options and are applied to the destination
+repository.
+
+With --repo or --all, all snapshots of the given repositories are listed.
+
+Examples:
+  # Snapshots created by a job
+  crestic snapshots --job documents
+
+  # Snapshots of several jobs, merged by time
+  crestic snapshots --job documents,photos
+
+  # All snapshots of all repositories
+  crestic snapshots --all
+
+  # Snapshots of a job created on another host, as JSON
+  crestic snapshots --job documents --host laptop --json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		queries, err := snapshotQueries(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			snapshots.NewHandler(restic.NewService(executor)),
+			handler.WithPanicRecovery[*snapshots.Command](),
+		)
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+		return h.Handle(cmd.Context(), &snapshots.Command{
+			Queries: queries,
+			JSON:    asJSON,
+			Out:     os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotsCmd)
+	snapshotsCmd.Flags().StringSliceP("job", "j", nil, "List snapshots created by specific jobs (comma-separated)")
+	snapshotsCmd.Flags().StringSliceP("repo", "r", nil, "List all snapshots of specific repositories")
+	snapshotsCmd.Flags().BoolP("all", "a", false, "List all snapshots of all repositories")
+	snapshotsCmd.Flags().String("host", "", "Only list snapshots created on this host")
+
+	_ = snapshotsCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+	_ = snapshotsCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+}
+
+// snapshotQueries builds one query per selected job and per selected repository.
+func snapshotQueries(cmd *cobra.Command, cfg *entity.Config) ([]entity.SnapshotQuery, error) {
+	host, _ := cmd.Flags().GetString("host")
+	jobNames, _ := cmd.Flags().GetStringSlice("job")
+
+	jobs, err := getJobs(cfg, jobNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var queries []entity.SnapshotQuery
+	for _, j := range jobs {
+		jobQueries, ok := entity.SnapshotQueries(j)
+		if !ok {
+			return nil, fmt.Errorf("job %s doesn't create snapshots", j.GetName())
+		}
+
+		for _, q := range jobQueries {
+			if host != "" {
+				q.Filter.Host = host
+			}
+			queries = append(queries, q)
+		}
+	}
+
+	repoNames, _ := cmd.Flags().GetStringSlice("repo")
+	all, _ := cmd.Flags().GetBool("all")
+	if len(repoNames) > 0 || all {
+		repos, rErr := getRepos(cmd, cfg)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		for _, r := range repos {
+			queries = append(queries, entity.SnapshotQuery{Repo: r, Filter: entity.SnapshotFilter{Host: host}})
+		}
+	}
+
+	if len(queries) == 0 {
+		return nil, errors.New("either --job, --repo or --all must be specified")
+	}
+
+	return queries, nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/cases/status"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var statusCmd = &cobra.Command{
+	Use:         "status",
+	Short:       "Show an overview of repositories and jobs",
+	Annotations: dataOutput,
+	Long: `Show an overview of all configured repositories and the jobs writing to them.
+
+For each repository the command reports:
+  - whether it is initialized and reachable
+  - the number of snapshots and the time of the latest one
+  - the total size of stored data (restic stats --mode raw-data)
+  - existing locks, and how many of them are stale (older than 30 minutes)
+
+For each backup and copy job it shows the latest matching snapshot
+(by the job's paths, tags and host) and its age, followed by the same
+information per tag in JSON output.
+
+Repositories are queried concurrently. Locks are listed without locking
+the repository.
+
+Examples:
+  # Overview of all repositories
+  crestic status
+
+  # Only specific repositories
+  crestic status --repo local-backup,remote-backup
+
+  # Output as JSON
+  crestic status --json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		repoNames, _ := cmd.Flags().GetStringSlice("repo")
+		if len(repoNames) == 0 {
+			repoNames = lo.Keys(cfg.Repositories)
+		}
+
+		err = validateGivenRepoNames(cfg, repoNames)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			status.NewHandler(restic.NewService(executor)),
+			handler.WithPanicRecovery[*status.Command](),
+		)
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+		return h.Handle(cmd.Context(), &status.Command{
+			Repos: lo.Map(repoNames, func(name string, _ int) *entity.Repository {
+				return cfg.Repositories[name]
+			}),
+			Jobs:  cfg.Jobs,
+			JSON:  asJSON,
+			Color: isTerminal(os.Stdout),
+			Out:   os.Stdout,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringSliceP("repo", "r", nil, "Show specific repository/repositories (default: all)")
+
+	_ = statusCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/alexander-kolodka/crestic/internal/cases/handler"
@@ -27,6 +29,11 @@ WARNING: Only run this command if you're absolutely certain that no other
 crestic or restic process is currently accessing the repository. Running unlock
 while another operation is in progress can cause data corruption.
 
+All selected repositories are processed even if some of them fail. When more than
+one repository is involved, a summary is printed at the end, and the command exits
+with a non-zero status if any repository failed. With --healthcheck the result is
+reported to Healthchecks.io, like for 'crestic backup'.
+
 Examples:
   # Unlock all repositories
   crestic unlock --all
@@ -48,14 +55,20 @@ Examples:
 			return err
 		}
 
+		hc, err := healthChecksFromFlags(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
 		executor := shell.NewExecutor()
 		h := handler.Chain(
-			unlock.NewHandler(restic.NewService(executor)),
+			unlock.NewHandler(restic.NewService(executor), hc),
 			handler.WithPanicRecovery[*unlock.Command](),
 		)
 
 		return h.Handle(cmd.Context(), &unlock.Command{
 			Repos: repos,
+			Out:   os.Stdout,
 		})
 	},
 }
@@ -64,6 +77,7 @@ func init() {
 	rootCmd.AddCommand(unlockCmd)
 	unlockCmd.Flags().StringSliceP("repo", "r", nil, "Unlock specific repository/repositories (can specify multiple)")
 	unlockCmd.Flags().BoolP("all", "a", false, "Unlock all repositories")
+	addHealthcheckFlags(unlockCmd)
 
 	_ = unlockCmd.RegisterFlagCompletionFunc("repo", repoAutocompletion)
 }
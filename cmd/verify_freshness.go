@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/freshness"
+	"github.com/alexander-kolodka/crestic/internal/cases/handler"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+var verifyFreshnessCmd = &cobra.Command{
+	Use:   "verify-freshness",
+	Short: "Fail when the latest snapshot of a job is too old",
+	Long: `Verify that every job has a recent enough snapshot in its repository.
+
+For each job with max_age configured, the command looks up the latest snapshot
+matching the job's paths, tags and host (restic snapshots --json) and fails
+if it is older than max_age or if no snapshot exists.
+
+Unlike healthchecks sent by 'crestic backup', this detects missed backups even
+when crestic itself never runs (e.g. a broken crontab). It only reads snapshots,
+so it can run on a separate monitoring host with the same configuration.
+
+The command exits with a non-zero status if any job is stale. With --healthcheck
+the result is also reported to Healthchecks.io. Use a separate check for
+freshness monitoring with --healthcheck-url.
+
+Examples:
+  # Verify all jobs with max_age
+  crestic verify-freshness --all
+
+  # Verify a single job with an explicit limit
+  crestic verify-freshness --job documents --max-age 2d
+
+  # Verify snapshots created on another host and notify a dedicated check
+  crestic verify-freshness --all --host nas --healthcheck \
+    --healthcheck-url https://hc-ping.com/your-freshness-uuid`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			return err
+		}
+
+		var maxAge time.Duration
+		maxAgeStr, _ := cmd.Flags().GetString("max-age")
+		if maxAgeStr != "" {
+			maxAge, err = entity.ParseDuration(maxAgeStr)
+			if err != nil {
+				return err
+			}
+		}
+
+		jobs := filterJobs(cmd, cfg.Jobs)
+		if len(jobs) == 0 {
+			return errEmptyJobSelection
+		}
+
+		hc, err := healthChecksFromFlags(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		executor := shell.NewExecutor()
+		h := handler.Chain(
+			freshness.NewHandler(restic.NewService(executor), hc),
+			handler.WithPanicRecovery[*freshness.Command](),
+		)
+
+		host, _ := cmd.Flags().GetString("host")
+		return h.Handle(cmd.Context(), &freshness.Command{
+			Jobs:   jobs,
+			MaxAge: maxAge,
+			Host:   host,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyFreshnessCmd)
+	verifyFreshnessCmd.Flags().BoolP("all", "a", false, "Verify all jobs with max_age")
+	verifyFreshnessCmd.Flags().StringSliceP("job", "j", nil, "Verify only specific jobs by name (comma-separated)")
+	verifyFreshnessCmd.Flags().String("max-age", "", "Override max_age of the selected jobs (e.g. 36h, 2d)")
+	verifyFreshnessCmd.Flags().String("host", "", "Only consider snapshots created on this host")
+	addHealthcheckFlags(verifyFreshnessCmd)
+
+	_ = verifyFreshnessCmd.RegisterFlagCompletionFunc("job", jobAutocompletion)
+}
@@ -6,15 +6,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/samber/lo"
 
 	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/fssnapshot"
 	"github.com/alexander-kolodka/crestic/internal/healthchecks"
 	"github.com/alexander-kolodka/crestic/internal/logger"
 	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/restoretest"
+	"github.com/alexander-kolodka/crestic/internal/runhistory"
 	"github.com/alexander-kolodka/crestic/internal/shell"
 )
 
@@ -24,17 +29,33 @@ type Command struct {
 }
 
 type Handler struct {
-	restic *restic.Service
-	runner *shell.Executor
-	hc     HealthChecks
+	restic  *restic.Service
+	runner  *shell.Executor
+	fs      *fssnapshot.Manager
+	hc      HealthChecks
+	history History
+	tester  RestoreTester
+}
+
+type RestoreTester interface {
+	Run(ctx context.Context, job entity.BackupJob, rt entity.RestoreTest) (*restoretest.Report, error)
 }
 
 // NewHandler creates a backup command Handler.
-func NewHandler(restic *restic.Service, runner *shell.Executor, hc HealthChecks) *Handler {
+func NewHandler(
+	restic *restic.Service,
+	runner *shell.Executor,
+	hc HealthChecks,
+	history History,
+	tester RestoreTester,
+) *Handler {
 	return &Handler{
-		restic: restic,
-		runner: runner,
-		hc:     hc,
+		restic:  restic,
+		runner:  runner,
+		fs:      fssnapshot.NewManager(runner),
+		hc:      hc,
+		history: history,
+		tester:  tester,
 	}
 }
 
@@ -47,18 +68,38 @@ func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
 	fn := chain(
 		h.doJob,
 		newHookMw(h),
+		newTimeoutMw(),
+		newInterruptMw(),
 	)
 
 	rid := uuid.NewString()
 	_ = h.hc.Start(ctx, rid, healthchecks.NewJobsList(toJobList(cmd.Jobs)))
 
+	run := runhistory.NewRun(rid, time.Now())
 	jobResults := entity.NewJobResults()
 	for _, job := range cmd.Jobs {
+		ie, interrupted := shell.Interrupted(ctx)
+		if interrupted {
+			jobResults.Interrupt(ie.Error())
+			break
+		}
+
+		report := &jobReport{}
 		start := time.Now()
-		err := fn(ctx, job)
-		jobResults.Add(job.GetName(), time.Since(start), err)
+		err := fn(withReport(ctx, report), job)
+		end := time.Now()
+		jobResults.Add(job.GetName(), end.Sub(start), err)
+		run.AddJob(toHistoryJob(job, report, start, end, err))
 	}
 
+	run.Finish(time.Now())
+	if !cmd.DryRun {
+		h.saveHistory(ctx, run)
+	}
+
+	// The results are reported even if the run was interrupted.
+	ctx = context.WithoutCancel(ctx)
+
 	if jobResults.HasErrors() {
 		_ = h.hc.Fail(ctx, rid, jobResults)
 		return errors.New(jobResults.ErrorMsg())
@@ -92,6 +133,29 @@ func (h *Handler) doJob(ctx context.Context, job entity.Job) error {
 
 		log.Error().Msg("Copy job failed")
 		return err
+	case entity.CheckJob:
+		return h.maintain(ctx, j, j.Repos, func(ctx context.Context, r *entity.Repository) error {
+			return h.restic.Check(ctx, r, j.Options())
+		})
+	case entity.PruneJob:
+		return h.maintain(ctx, j, j.Repos, func(ctx context.Context, r *entity.Repository) error {
+			return h.restic.Prune(ctx, r, j.Options())
+		})
+	case entity.ForgetJob:
+		return h.maintain(ctx, j, j.Repos, func(ctx context.Context, r *entity.Repository) error {
+			return h.restic.ForgetWithOptions(ctx, r, r.ForgetOptions.Merge(j.Options))
+		})
+	case entity.CommandJob:
+		jobCtx := logger.FromContext(ctx).With().Str("job", j.Name).Logger().WithContext(ctx)
+		log := logger.FromContext(jobCtx)
+
+		err := h.command(jobCtx, j)
+		if err == nil {
+			return nil
+		}
+
+		log.Error().Msg("Command job failed")
+		return err
 	default:
 	}
 
@@ -108,12 +172,23 @@ func (h *Handler) backup(ctx context.Context, b entity.BackupJob) error {
 		return err
 	}
 
-	err = h.restic.Backup(ctx, b)
+	summaries, err := h.snapshotSources(ctx, b)
 	if err != nil {
 		return err
 	}
 
-	err = h.restic.Check(ctx, b.To)
+	report := reportFromContext(ctx)
+	report.snapshotID = strings.Join(lo.FilterMap(summaries, func(s *restic.BackupSummary, _ int) (string, bool) {
+		return s.SnapshotID, s.SnapshotID != ""
+	}), ",")
+	if len(summaries) == 1 {
+		report.stats = summaries[0].Stats
+	}
+	if lo.EveryBy(summaries, func(s *restic.BackupSummary) bool { return s.Skipped }) {
+		report.skippedReason = "no changes since the last snapshot"
+	}
+
+	err = h.restic.Check(ctx, b.To, nil)
 	if err != nil {
 		return err
 	}
@@ -123,9 +198,78 @@ func (h *Handler) backup(ctx context.Context, b entity.BackupJob) error {
 		return err
 	}
 
+	if b.RestoreTest != nil && b.RestoreTest.AfterBackup && !restic.IsDryRun(ctx) {
+		_, err = h.tester.Run(ctx, b, *b.RestoreTest)
+		if err != nil {
+			return fmt.Errorf("restore test: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// snapshotSources creates the restic snapshots of a backup job.
+// If configured, the sources are read from a filesystem snapshot,
+// which is removed afterwards even if the backup failed or was interrupted.
+func (h *Handler) snapshotSources(
+	ctx context.Context,
+	b entity.BackupJob,
+) (summaries []*restic.BackupSummary, err error) {
+	if b.Snapshot == nil || restic.IsDryRun(ctx) {
+		return h.snapshot(ctx, b)
+	}
+
+	fs, err := h.fs.Create(ctx, *b.Snapshot, fmt.Sprintf("%s-%s", b.Name, time.Now().Format("20060102150405")))
+	if err != nil {
+		return nil, fmt.Errorf("filesystem snapshot: %w", err)
+	}
+
+	defer func() {
+		// The snapshot must be removed even if ctx was canceled by SIGINT/SIGTERM.
+		rErr := h.fs.Remove(context.WithoutCancel(ctx), fs)
+		if rErr != nil {
+			err = errors.Join(err, fmt.Errorf("remove filesystem snapshot: %w", rErr))
+		}
+	}()
+
+	mapping := restic.PathMapping{}
+	for _, from := range b.From {
+		mapping[from] = fs.Path(from)
+	}
+
+	return h.snapshot(restic.WithPathMapping(ctx, mapping), b)
+}
+
+// snapshot creates the snapshots of a backup job: a single one for its sources,
+// or one per database dump. A failing dump doesn't prevent the remaining ones.
+func (h *Handler) snapshot(ctx context.Context, b entity.BackupJob) ([]*restic.BackupSummary, error) {
+	if len(b.Dumps) == 0 {
+		summary, err := h.restic.Backup(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		return []*restic.BackupSummary{summary}, nil
+	}
+
+	var summaries []*restic.BackupSummary
+	var errs []error
+	for _, d := range b.Dumps {
+		dumpCtx := logger.FromContext(ctx).With().
+			Str("database", d.Database).
+			Str("database_kind", d.Kind).
+			Logger().WithContext(ctx)
+
+		summary, err := h.restic.Backup(dumpCtx, b.ForDump(d))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("database %s: %w", d.Database, err))
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, errors.Join(errs...)
+}
+
 func (h *Handler) copy(ctx context.Context, c entity.CopyJob) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Processing copy")
@@ -135,7 +279,7 @@ func (h *Handler) copy(ctx context.Context, c entity.CopyJob) error {
 		return err
 	}
 
-	err = h.initRepo(ctx, c.To)
+	err = h.initRepo(ctx, copyTarget(c))
 	if err != nil {
 		return err
 	}
@@ -145,7 +289,7 @@ func (h *Handler) copy(ctx context.Context, c entity.CopyJob) error {
 		return err
 	}
 
-	err = h.restic.Check(ctx, c.To)
+	err = h.restic.Check(ctx, c.To, nil)
 	if err != nil {
 		return err
 	}
@@ -158,17 +302,81 @@ func (h *Handler) copy(ctx context.Context, c entity.CopyJob) error {
 	return nil
 }
 
-func (h *Handler) initRepo(ctx context.Context, repo *entity.Repository) error {
-	isRepoInitialized, err := h.restic.IsRepoInitialized(ctx, repo)
-	if err != nil {
-		return err
+func (h *Handler) command(ctx context.Context, c entity.CommandJob) error {
+	log := logger.FromContext(ctx)
+	log.Info().Msg("Processing command")
+
+	env := map[string]string{"CRESTIC_JOB_NAME": c.Name}
+	maps.Copy(env, c.Env)
+
+	ctx = shell.WithEnv(ctx, env)
+	ctx = shell.WithDir(ctx, c.Dir)
+	ctx = logger.WithSource(ctx, "command")
+
+	for _, command := range c.Run {
+		if restic.IsDryRun(ctx) {
+			log.Info().
+				Str("command", command).
+				Msg("DRY RUN: would run command")
+			continue
+		}
+
+		result := h.runner.Run(ctx, "sh", "-c", command)
+		if result.Error != nil {
+			return shellError("command", command, result)
+		}
+	}
+
+	return nil
+}
+
+// maintain runs a maintenance operation on every repository of the job.
+// A failing repository doesn't prevent the remaining ones from being processed.
+func (h *Handler) maintain(
+	ctx context.Context,
+	job entity.Job,
+	repos []*entity.Repository,
+	fn func(ctx context.Context, r *entity.Repository) error,
+) error {
+	ctx = logger.FromContext(ctx).With().Str("job", job.GetName()).Logger().WithContext(ctx)
+
+	var errs []error
+	for _, repo := range repos {
+		repoCtx := logger.WithRepoFields(ctx, repo)
+		err := fn(repoCtx, repo)
+		if err != nil {
+			log := logger.FromContext(repoCtx)
+			log.Error().Err(err).Msg("Maintenance job failed")
+			errs = append(errs, fmt.Errorf("repository %s: %w", repo.Name, err))
+		}
 	}
 
-	if isRepoInitialized {
-		return nil
+	return errors.Join(errs...)
+}
+
+func (h *Handler) initRepo(ctx context.Context, repo *entity.Repository) error {
+	return h.restic.EnsureInitialized(ctx, repo)
+}
+
+// copyTarget returns the destination of a copy job as it is initialized if missing:
+// with the chunker parameters of the source, so copied snapshots are deduplicated,
+// unless the repository's init_options name another source.
+func copyTarget(c entity.CopyJob) *entity.Repository {
+	if c.To.InitOptions.CopyChunkerParamsFrom != nil {
+		return c.To
 	}
 
-	return h.restic.Init(ctx, repo)
+	to := *c.To
+	to.InitOptions.CopyChunkerParamsFrom = c.From
+	return &to
+}
+
+func (h *Handler) saveHistory(ctx context.Context, run *runhistory.Run) {
+	err := h.history.Save(run)
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().Err(err).Msg("Failed to save run history")
+	}
 }
 
 func (h *Handler) executeHooks(ctx context.Context, hooks []string) error {
@@ -176,17 +384,23 @@ func (h *Handler) executeHooks(ctx context.Context, hooks []string) error {
 	for _, hook := range hooks {
 		result := h.runner.Run(ctx, "sh", "-c", hook)
 		if result.Error != nil {
-			return fmt.Errorf(
-				`hook failed "%s" [exit code %d]: %w`,
-				hook,
-				result.ExitCode,
-				result.Error,
-			)
+			return shellError("hook", hook, result)
 		}
 	}
 	return nil
 }
 
+// shellError describes a failed command or hook. Timeouts are reported as such,
+// as the exit code of a killed command is meaningless.
+func shellError(kind, command string, result *shell.Result) error {
+	if result.TimedOut {
+		// The error of a timed out command reads "timed out after ...".
+		return fmt.Errorf(`%s "%s" %w`, kind, command, result.Error)
+	}
+
+	return fmt.Errorf(`%s failed "%s" [exit code %d]: %w`, kind, command, result.ExitCode, result.Error)
+}
+
 func toJobList(jobs []entity.Job) []string {
 	return lo.Map(jobs, func(j entity.Job, _ int) string {
 		return j.GetName()
@@ -0,0 +1,349 @@
+package backup_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/backup"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/runhistory"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic records restic invocations and fails for repositories with path "broken".
+// Repositories with path "missing" are reported as not initialized.
+type fakeRestic struct {
+	calls []string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	f.calls = append(f.calls, strings.Join(args, " "))
+
+	path := args[slices.Index(args, "-r")+1]
+	switch {
+	case path == "broken":
+		return &shell.Result{ExitCode: 1, Error: errors.New("repository is damaged")}
+	case path == "missing" && args[0] == "stats":
+		return &shell.Result{ExitCode: 10, Error: errors.New("repository does not exist")}
+	}
+	return &shell.Result{}
+}
+
+type fakeHistory struct {
+	runs []*runhistory.Run
+}
+
+func (f *fakeHistory) Save(run *runhistory.Run) error {
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func newHandler(fake *fakeRestic, history *fakeHistory) *backup.Handler {
+	return backup.NewHandler(restic.NewService(fake), shell.NewExecutor(), &healthchecks.Dummy{}, history, nil)
+}
+
+func repo(path string) *entity.Repository {
+	return &entity.Repository{Name: path, Path: path, PasswordCMD: "pass"}
+}
+
+func TestCheckJobContinuesAfterFailedRepository(t *testing.T) {
+	fake := &fakeRestic{}
+	history := &fakeHistory{}
+
+	err := newHandler(fake, history).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CheckJob{
+			Name:           "weekly-check",
+			Repos:          []*entity.Repository{repo("broken"), repo("b")},
+			ReadDataSubset: "5%",
+		}},
+	})
+	require.ErrorContains(t, err, "repository broken")
+
+	assert.Equal(t, []string{
+		"check -r broken --password-command pass --read-data-subset 5%",
+		"check -r b --password-command pass --read-data-subset 5%",
+	}, fake.calls)
+
+	require.Len(t, history.runs, 1)
+	job := history.runs[0].Jobs[0]
+	assert.Equal(t, "check", job.Type)
+	assert.Equal(t, "broken,b", job.Repository)
+	assert.Equal(t, runhistory.StatusFailed, job.Status)
+}
+
+func TestPruneJob(t *testing.T) {
+	fake := &fakeRestic{}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.PruneJob{
+			Name:      "prune",
+			Repos:     []*entity.Repository{repo("a")},
+			MaxUnused: "10%",
+		}},
+		DryRun: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"prune -r a --password-command pass --dry-run --max-unused 10%"}, fake.calls)
+}
+
+func TestForgetJobOverridesRepositoryOptions(t *testing.T) {
+	fake := &fakeRestic{}
+	a := repo("a")
+	a.ForgetOptions = entity.Options{"keep-last": 5}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.ForgetJob{
+			Name:    "forget",
+			Repos:   []*entity.Repository{a},
+			Options: entity.Options{"keep-last": 3},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"forget -r a --password-command pass --keep-last 3"}, fake.calls)
+	assert.Equal(t, entity.Options{"keep-last": 5}, a.ForgetOptions)
+}
+
+func TestCommandJob(t *testing.T) {
+	dir := t.TempDir()
+	history := &fakeHistory{}
+
+	err := newHandler(&fakeRestic{}, history).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CommandJob{
+			Name: "dump",
+			Run:  []string{`echo "$GREETING $CRESTIC_JOB_NAME" > out.txt`},
+			Dir:  dir,
+			Env:  map[string]string{"GREETING": "hello"},
+		}},
+	})
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello dump\n", string(b))
+
+	require.Len(t, history.runs, 1)
+	assert.Equal(t, "command", history.runs[0].Jobs[0].Type)
+	assert.Equal(t, runhistory.StatusSuccess, history.runs[0].Jobs[0].Status)
+}
+
+func TestCommandJobStopsAtFirstFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	err := newHandler(&fakeRestic{}, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CommandJob{
+			Name: "sync",
+			Run:  []string{"exit 3", "touch never"},
+			Dir:  dir,
+		}},
+	})
+	require.ErrorContains(t, err, "exit code 3")
+	assert.NoFileExists(t, filepath.Join(dir, "never"))
+}
+
+func TestBackupFromCommand(t *testing.T) {
+	fake := &fakeRestic{}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.BackupJob{
+			Name:        "db",
+			To:          repo("a"),
+			FromCommand: &entity.CommandSource{Command: "pg_dump -Fc mydb", Filename: "mydb.dump"},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, fake.calls,
+		"backup -r a --password-command pass --stdin-filename mydb.dump --stdin-from-command -- sh -c pg_dump -Fc mydb",
+	)
+}
+
+func TestCopyInitializesTargetWithSourceChunkerParams(t *testing.T) {
+	fake := &fakeRestic{}
+	to := repo("missing")
+	to.AutoInit = true
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CopyJob{Name: "offsite", From: repo("a"), To: to}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, fake.calls,
+		"init -r missing --password-command pass --copy-chunker-params --from-repo a --from-password-command pass",
+	)
+	assert.Nil(t, to.InitOptions.CopyChunkerParamsFrom, "the configured repository is not modified")
+}
+
+func TestBackupDoesNotInitializeRepositoryWithoutAutoInit(t *testing.T) {
+	fake := &fakeRestic{}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.BackupJob{Name: "docs", From: []string{"/docs"}, To: repo("missing")}},
+	})
+	require.ErrorContains(t, err, "repository missing is not initialized and auto_init is disabled")
+
+	assert.Equal(t, []string{"stats -r missing --password-command pass"}, fake.calls)
+}
+
+func TestCopyDoesNotInitializeTargetWithoutAutoInit(t *testing.T) {
+	fake := &fakeRestic{}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CopyJob{Name: "offsite", From: repo("a"), To: repo("missing")}},
+	})
+	require.ErrorContains(t, err, "repository missing is not initialized and auto_init is disabled")
+
+	assert.False(t, slices.ContainsFunc(fake.calls, func(c string) bool {
+		return strings.HasPrefix(c, "init") || strings.HasPrefix(c, "copy")
+	}))
+}
+
+func TestCopyDoesNotInitializeTargetWithConflictingEnv(t *testing.T) {
+	fake := &fakeRestic{}
+	from := repo("a")
+	from.Env = map[string]string{"AWS_ACCESS_KEY_ID": "SRCKEY"}
+	to := repo("missing")
+	to.AutoInit = true
+	to.Env = map[string]string{"AWS_ACCESS_KEY_ID": "DSTKEY"}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CopyJob{Name: "offsite", From: from, To: to}},
+	})
+	require.ErrorContains(t, err, "repositories missing and a set env AWS_ACCESS_KEY_ID to different values")
+
+	assert.False(t, slices.ContainsFunc(fake.calls, func(c string) bool {
+		return strings.HasPrefix(c, "init") || strings.HasPrefix(c, "copy")
+	}), "the target is neither created nor copied to with the source's env")
+}
+
+func TestBackupDumpsOneSnapshotPerDatabase(t *testing.T) {
+	fake := &fakeRestic{}
+
+	err := newHandler(fake, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.BackupJob{
+			Name:    "db",
+			To:      repo("a"),
+			Options: entity.Options{"tag": []any{"nightly"}},
+			Dumps: []entity.Dump{
+				{Kind: entity.DumpPostgres, Database: "app", Source: entity.CommandSource{Command: "dump app", Filename: "app.dump"}},
+				{Kind: entity.DumpPostgres, Database: "crm", Source: entity.CommandSource{Command: "dump crm", Filename: "crm.dump"}},
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	backups := slices.DeleteFunc(slices.Clone(fake.calls), func(c string) bool {
+		return !strings.HasPrefix(c, "backup ")
+	})
+	assert.Equal(t, []string{
+		"backup -r a --password-command pass --tag nightly --tag postgres --tag db:app " +
+			"--stdin-filename app.dump --stdin-from-command -- sh -c dump app",
+		"backup -r a --password-command pass --tag nightly --tag postgres --tag db:crm " +
+			"--stdin-filename crm.dump --stdin-from-command -- sh -c dump crm",
+	}, backups)
+}
+
+func TestJobTimeoutRunsFailureHooks(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "failed")
+
+	err := newHandler(&fakeRestic{}, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CommandJob{
+			Name:    "slow",
+			Run:     []string{"sleep 5"},
+			Timeout: 100 * time.Millisecond,
+			Hooks:   entity.Hooks{Failure: []string{`echo "$CRESTIC_ERROR" > ` + marker}},
+		}},
+	})
+	require.ErrorContains(t, err, "job timed out after 100ms")
+
+	b, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "timed out")
+}
+
+func TestCommandJobDryRun(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "synced")
+
+	err := newHandler(&fakeRestic{}, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CommandJob{
+			Name: "sync",
+			Run:  []string{"touch " + marker},
+		}},
+		DryRun: true,
+	})
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, marker, "commands aren't run in dry-run mode")
+}
+
+func TestHookTimeout(t *testing.T) {
+	err := newHandler(&fakeRestic{}, &fakeHistory{}).Handle(context.Background(), &backup.Command{
+		Jobs: []entity.Job{entity.CommandJob{
+			Name:  "hooked",
+			Run:   []string{"true"},
+			Hooks: entity.Hooks{Before: []string{"sleep 5"}, Timeout: 100 * time.Millisecond},
+		}},
+	})
+	require.ErrorContains(t, err, "before hooks failed")
+	require.ErrorContains(t, err, `hook "sleep 5" timed out after 100ms`)
+}
+
+// recordingHC records the results of failed runs and the context state they were sent with.
+type recordingHC struct {
+	healthchecks.Dummy
+	failed      *entity.JobResults
+	ctxCanceled bool
+}
+
+func (r *recordingHC) Fail(ctx context.Context, _ string, results *entity.JobResults) error {
+	r.failed = results
+	r.ctxCanceled = ctx.Err() != nil
+	return nil
+}
+
+func TestInterruptedRun(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "failed")
+	hc := &recordingHC{}
+	h := backup.NewHandler(restic.NewService(&fakeRestic{}), shell.NewExecutor(), hc, &fakeHistory{}, nil)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(100*time.Millisecond, func() {
+		cancel(&shell.InterruptedError{Signal: syscall.SIGTERM})
+	})
+
+	err := h.Handle(ctx, &backup.Command{
+		Jobs: []entity.Job{
+			entity.CommandJob{
+				Name:  "long",
+				Run:   []string{"sleep 5"},
+				Hooks: entity.Hooks{Failure: []string{`echo "$CRESTIC_ERROR" > ` + marker}},
+			},
+			entity.CommandJob{Name: "never", Run: []string{"true"}},
+		},
+	})
+	require.ErrorContains(t, err, "interrupted by terminated")
+
+	b, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "interrupted by terminated")
+
+	require.NotNil(t, hc.failed)
+	assert.False(t, hc.ctxCanceled)
+	assert.Equal(t, "interrupted by terminated", hc.failed.Interrupted)
+	require.Len(t, hc.failed.FailedJobs, 1)
+	assert.Equal(t, "long", hc.failed.FailedJobs[0].Name)
+	assert.Empty(t, hc.failed.SuccessJobs)
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/runhistory"
+)
+
+type History interface {
+	Save(run *runhistory.Run) error
+}
+
+// jobReport collects details of a job run that are recorded in the run history.
+type jobReport struct {
+	snapshotID    string
+	skippedReason string
+	stats         *entity.BackupStats
+}
+
+type reportKey struct{}
+
+func withReport(ctx context.Context, r *jobReport) context.Context {
+	return context.WithValue(ctx, reportKey{}, r)
+}
+
+// reportFromContext returns the report of the current job.
+// A detached report is returned if the job is not being recorded.
+func reportFromContext(ctx context.Context) *jobReport {
+	r, ok := ctx.Value(reportKey{}).(*jobReport)
+	if !ok {
+		return &jobReport{}
+	}
+	return r
+}
+
+func toHistoryJob(job entity.Job, report *jobReport, start, end time.Time, err error) runhistory.Job {
+	j := runhistory.Job{
+		Name:          job.GetName(),
+		Start:         start,
+		End:           end,
+		Status:        runhistory.StatusSuccess,
+		SnapshotID:    report.snapshotID,
+		SkippedReason: report.skippedReason,
+		Stats:         report.stats,
+	}
+
+	switch v := job.(type) {
+	case entity.BackupJob:
+		j.Type = "backup"
+		j.Repository = v.To.Name
+	case entity.CopyJob:
+		j.Type = "copy"
+		j.Repository = v.To.Name
+	case entity.CheckJob:
+		j.Type = "check"
+		j.Repository = repoNames(v.Repos)
+	case entity.PruneJob:
+		j.Type = "prune"
+		j.Repository = repoNames(v.Repos)
+	case entity.ForgetJob:
+		j.Type = "forget"
+		j.Repository = repoNames(v.Repos)
+	case entity.CommandJob:
+		j.Type = "command"
+	default:
+	}
+
+	switch {
+	case err != nil:
+		j.Status = runhistory.StatusFailed
+		j.Error = err.Error()
+	case report.skippedReason != "":
+		j.Status = runhistory.StatusSkipped
+	}
+
+	return j
+}
+
+func repoNames(repos []*entity.Repository) string {
+	return strings.Join(lo.Map(repos, func(r *entity.Repository, _ int) string {
+		return r.Name
+	}), ",")
+}
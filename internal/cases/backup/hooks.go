@@ -17,20 +17,22 @@ func newHookMw(h hookExecutor) mw {
 		return func(ctx context.Context, j entity.Job) error {
 			hooks := j.GetHooks()
 			jName := j.GetName()
+			hookCtx := shell.WithTimeout(ctx, hooks.Timeout)
 
-			err := h.executeHooks(withEnv(ctx, jName, nil), hooks.Before)
+			err := h.executeHooks(withEnv(hookCtx, jName, nil), hooks.Before)
 			if err != nil {
-				_ = h.executeHooks(withEnv(ctx, jName, err), hooks.Failure)
+				_ = h.executeHooks(withEnv(context.WithoutCancel(hookCtx), jName, err), hooks.Failure)
 				return fmt.Errorf("before hooks failed: %w", err)
 			}
 
 			err = fn(ctx, j)
 			if err != nil {
-				_ = h.executeHooks(withEnv(ctx, jName, err), hooks.Failure)
+				// Failure hooks also run if the job was interrupted by a signal.
+				_ = h.executeHooks(withEnv(context.WithoutCancel(hookCtx), jName, err), hooks.Failure)
 				return err
 			}
 
-			return h.executeHooks(withEnv(ctx, jName, nil), hooks.Success)
+			return h.executeHooks(withEnv(hookCtx, jName, nil), hooks.Success)
 		}
 	}
 }
@@ -44,5 +46,10 @@ func withEnv(ctx context.Context, jobName string, err error) context.Context {
 		env["CRESTIC_ERROR"] = err.Error()
 	}
 
+	snapshotID := reportFromContext(ctx).snapshotID
+	if err == nil && snapshotID != "" {
+		env["CRESTIC_SNAPSHOT_ID"] = snapshotID
+	}
+
 	return shell.WithEnv(ctx, env)
 }
@@ -0,0 +1,24 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// newInterruptMw marks errors of jobs stopped by a signal, so failure hooks
+// and healthchecks report the interruption instead of a bare exit status.
+func newInterruptMw() mw {
+	return func(fn do) do {
+		return func(ctx context.Context, j entity.Job) error {
+			err := fn(ctx, j)
+			ie, interrupted := shell.Interrupted(ctx)
+			if err != nil && interrupted {
+				return fmt.Errorf("%w: %w", ie, err)
+			}
+			return err
+		}
+	}
+}
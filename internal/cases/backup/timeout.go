@@ -0,0 +1,31 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+// newTimeoutMw limits the run time of a job. When the timeout is exceeded,
+// the running command is terminated and the job fails.
+func newTimeoutMw() mw {
+	return func(fn do) do {
+		return func(ctx context.Context, j entity.Job) error {
+			timeout := j.GetTimeout()
+			if timeout <= 0 {
+				return fn(ctx, j)
+			}
+
+			jobCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			err := fn(jobCtx, j)
+			if err != nil && errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("job timed out after %s: %w", timeout, err)
+			}
+			return err
+		}
+	}
+}
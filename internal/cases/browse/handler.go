@@ -0,0 +1,57 @@
+package browse
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+type Command struct {
+	Repo *entity.Repository
+	In   io.Reader
+	Out  io.Writer
+}
+
+type Handler struct {
+	restic *restic.Service
+}
+
+func NewHandler(restic *restic.Service) *Handler {
+	return &Handler{
+		restic: restic,
+	}
+}
+
+// Handle runs an interactive browser over the snapshots of a repository
+// until the user quits or the input ends.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	ctx = logger.WithRepoFields(ctx, cmd.Repo)
+
+	snapshots, err := h.restic.Snapshots(ctx, cmd.Repo, entity.SnapshotFilter{})
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		return errors.New("repository has no snapshots")
+	}
+
+	slices.Reverse(snapshots)
+
+	s := &session{
+		restic:    h.restic,
+		repo:      cmd.Repo,
+		in:        bufio.NewScanner(cmd.In),
+		out:       cmd.Out,
+		snapshots: snapshots,
+		marked:    map[string]restic.Node{},
+	}
+
+	return s.run(ctx)
+}
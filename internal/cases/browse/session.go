@@ -0,0 +1,348 @@
+package browse
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+const help = `Snapshots:
+  <n>            open snapshot n
+Directories:
+  <n>            open directory n or show details of file n
+  ..             go to the parent directory
+  cd <path>      go to a directory (absolute or relative)
+  i <n>          show details of entry n
+  m <n>...       mark or unmark entries for restore (* marks all entries)
+  marked         list marked entries
+  clear          unmark all entries
+  r <target>     restore marked entries to the target directory
+  s              back to the snapshot list
+Anywhere:
+  <enter>        show the current listing again
+  ?              show this help
+  q              quit
+`
+
+var errInvalidSelection = errors.New("invalid selection, type ? for help")
+
+// session is the state of an interactive browser.
+// With no snapshot opened it shows the snapshot list, otherwise a directory of the snapshot.
+type session struct {
+	restic *restic.Service
+	repo   *entity.Repository
+	in     *bufio.Scanner
+	out    io.Writer
+
+	snapshots []restic.Snapshot // newest first
+	snapshot  *restic.Snapshot  // opened snapshot, nil in the snapshot list
+	tree      *tree
+	dir       string
+	marked    map[string]restic.Node
+	markedIn  string // ID of the snapshot the marked entries belong to
+}
+
+func (s *session) run(ctx context.Context) error {
+	s.printf("Browsing %d snapshots of repository %s. Type ? for help.\n\n", len(s.snapshots), s.repo.Name)
+	s.show()
+
+	for {
+		s.printf("%s> ", s.location())
+		if !s.in.Scan() {
+			s.printf("\n")
+			return s.in.Err()
+		}
+
+		quit, err := s.exec(ctx, strings.Fields(s.in.Text()))
+		if quit {
+			return nil
+		}
+
+		if err != nil {
+			s.printf("Error: %v\n", err)
+		}
+	}
+}
+
+func (s *session) exec(ctx context.Context, args []string) (bool, error) {
+	if len(args) == 0 {
+		s.show()
+		return false, nil
+	}
+
+	switch args[0] {
+	case "q", "quit", "exit":
+		return true, nil
+	case "?", "help":
+		s.printf("%s", help)
+		return false, nil
+	default:
+	}
+
+	if s.snapshot == nil {
+		return false, s.openSnapshot(ctx, args)
+	}
+
+	return false, s.execDir(ctx, args)
+}
+
+func (s *session) execDir(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "..":
+		return s.cd(path.Dir(s.dir))
+	case "cd":
+		if len(args) != 2 { //nolint:mnd // command and path
+			return errInvalidSelection
+		}
+		return s.cd(s.resolve(args[1]))
+	case "s", "snapshots":
+		s.snapshot = nil
+		s.show()
+		return nil
+	case "i":
+		return s.each(args[1:], s.info)
+	case "m":
+		return s.mark(args[1:])
+	case "marked":
+		s.showMarked()
+		return nil
+	case "clear":
+		clear(s.marked)
+		s.printf("No entries marked\n")
+		return nil
+	case "r":
+		if len(args) != 2 { //nolint:mnd // command and target
+			return errors.New("usage: r <target>")
+		}
+		return s.restore(ctx, args[1])
+	default:
+	}
+
+	n, err := s.entry(args[0])
+	if err != nil {
+		return err
+	}
+
+	if isDir(n) {
+		return s.cd(n.Path)
+	}
+
+	s.info(n)
+	return nil
+}
+
+func (s *session) openSnapshot(ctx context.Context, args []string) error {
+	i, err := strconv.Atoi(args[0])
+	if err != nil || i < 1 || i > len(s.snapshots) || len(args) != 1 {
+		return errInvalidSelection
+	}
+
+	snapshot := s.snapshots[i-1]
+	nodes, err := s.restic.Ls(ctx, s.repo, snapshot.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(s.marked) > 0 && s.markedIn != snapshot.ID {
+		s.printf("Cleared %d marked entries of the previous snapshot\n", len(s.marked))
+		clear(s.marked)
+	}
+
+	s.snapshot = &snapshot
+	s.markedIn = snapshot.ID
+	s.tree = newTree(nodes)
+	s.dir = s.tree.start()
+	s.show()
+	return nil
+}
+
+func (s *session) cd(dir string) error {
+	if !s.tree.isDir(dir) {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	s.dir = dir
+	s.show()
+	return nil
+}
+
+func (s *session) resolve(p string) string {
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	return path.Join(s.dir, p)
+}
+
+func (s *session) mark(args []string) error {
+	if len(args) == 1 && args[0] == "*" {
+		for _, n := range s.tree.list(s.dir) {
+			s.marked[n.Path] = n
+		}
+		s.printf("Marked: %d\n", len(s.marked))
+		return nil
+	}
+
+	err := s.each(args, func(n restic.Node) {
+		if _, ok := s.marked[n.Path]; ok {
+			delete(s.marked, n.Path)
+			return
+		}
+		s.marked[n.Path] = n
+	})
+	if err != nil {
+		return err
+	}
+
+	s.printf("Marked: %d\n", len(s.marked))
+	return nil
+}
+
+// each applies fn to the entries of the current directory selected by their numbers.
+// Nothing is applied if any number is invalid.
+func (s *session) each(args []string, fn func(restic.Node)) error {
+	if len(args) == 0 {
+		return errInvalidSelection
+	}
+
+	nodes := make([]restic.Node, 0, len(args))
+	for _, a := range args {
+		n, err := s.entry(a)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, n)
+	}
+
+	for _, n := range nodes {
+		fn(n)
+	}
+
+	return nil
+}
+
+func (s *session) entry(arg string) (restic.Node, error) {
+	entries := s.tree.list(s.dir)
+	i, err := strconv.Atoi(arg)
+	if err != nil || i < 1 || i > len(entries) {
+		return restic.Node{}, errInvalidSelection
+	}
+	return entries[i-1], nil
+}
+
+func (s *session) restore(ctx context.Context, target string) error {
+	if len(s.marked) == 0 {
+		return errors.New("no entries marked, use m <n> to mark entries")
+	}
+
+	paths := s.markedPaths()
+	err := s.restic.Restore(ctx, s.repo, s.snapshot.ID, restic.RestoreOptions{
+		Target:  target,
+		Include: lo.Map(paths, func(p string, _ int) string { return restic.EscapePattern(p) }),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.printf("Restored %d marked entries to %s\n", len(paths), target)
+	return nil
+}
+
+func (s *session) markedPaths() []string {
+	paths := lo.Keys(s.marked)
+	slices.Sort(paths)
+	return paths
+}
+
+func (s *session) location() string {
+	if s.snapshot == nil {
+		return "snapshots"
+	}
+	return s.snapshot.ShortID + ":" + s.dir
+}
+
+func (s *session) show() {
+	if s.snapshot == nil {
+		s.showSnapshots()
+		return
+	}
+	s.showDir()
+}
+
+func (s *session) showSnapshots() {
+	t := render.NewTable(s.out, "#", "ID", "TIME", "HOST", "TAGS", "PATHS")
+	for i, snap := range s.snapshots {
+		t.Row(
+			strconv.Itoa(i+1),
+			snap.ShortID,
+			render.Time(snap.Time),
+			snap.Hostname,
+			render.OrDash(strings.Join(snap.Tags, ",")),
+			strings.Join(snap.Paths, ","),
+		)
+	}
+	_ = t.Flush()
+}
+
+func (s *session) showDir() {
+	s.printf("%s\n", s.dir)
+
+	entries := s.tree.list(s.dir)
+	if len(entries) == 0 {
+		s.printf("(empty)\n")
+		return
+	}
+
+	t := render.NewTable(s.out, "", "#", "NAME", "SIZE", "MODIFIED")
+	for i, n := range entries {
+		mark, name, size := "", n.Name, render.Bytes(n.Size)
+		if _, ok := s.marked[n.Path]; ok {
+			mark = "*"
+		}
+		if isDir(n) {
+			name += "/"
+			size = "-"
+		}
+		t.Row(mark, strconv.Itoa(i+1), name, size, render.Time(n.MTime))
+	}
+	_ = t.Flush()
+}
+
+func (s *session) showMarked() {
+	if len(s.marked) == 0 {
+		s.printf("No entries marked\n")
+		return
+	}
+
+	for _, p := range s.markedPaths() {
+		s.printf("  %s\n", p)
+	}
+}
+
+func (s *session) info(n restic.Node) {
+	t := render.NewTable(s.out, "Path:", n.Path)
+	t.Row("Type:", n.Type)
+	if !isDir(n) {
+		t.Row("Size:", render.Bytes(n.Size))
+	}
+	t.Row("Mode:", os.FileMode(n.Mode).String())
+	t.Row("Owner:", fmt.Sprintf("%d:%d", n.UID, n.GID))
+	t.Row("Modified:", render.Time(n.MTime))
+	_ = t.Flush()
+}
+
+func (s *session) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(s.out, format, args...)
+}
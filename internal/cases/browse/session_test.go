@@ -0,0 +1,100 @@
+package browse_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/browse"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+type fakeRestic struct {
+	restores [][]string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	switch args[0] {
+	case "snapshots":
+		return &shell.Result{Stdout: `[
+			{"id":"1111111111","short_id":"11111111","time":"2026-01-01T00:00:00Z","hostname":"h","paths":["/data"]},
+			{"id":"2222222222","short_id":"22222222","time":"2026-01-02T00:00:00Z","hostname":"h","paths":["/data"]}
+		]`}
+	case "ls":
+		return &shell.Result{Stdout: strings.Join([]string{
+			`{"struct_type":"snapshot","id":"2222222222"}`,
+			`{"struct_type":"node","name":"data","type":"dir","path":"/data"}`,
+			`{"struct_type":"node","name":"b.txt","type":"file","path":"/data/b.txt","size":2048}`,
+			`{"struct_type":"node","name":"a[1].txt","type":"file","path":"/data/a[1].txt","size":10}`,
+			`{"struct_type":"node","name":"sub","type":"dir","path":"/data/sub"}`,
+			`{"struct_type":"node","name":"c.txt","type":"file","path":"/data/sub/c.txt","size":1}`,
+		}, "\n")}
+	case "restore":
+		f.restores = append(f.restores, args)
+		return &shell.Result{}
+	default:
+		return &shell.Result{}
+	}
+}
+
+func TestBrowseAndRestore(t *testing.T) {
+	fake := &fakeRestic{}
+	var out bytes.Buffer
+
+	input := strings.Join([]string{
+		"1",     // open the newest snapshot
+		"m 2 3", // mark a[1].txt and b.txt
+		"1",     // enter sub/
+		"m 1",   // mark c.txt
+		"..",    // back to /data
+		"m 3",   // unmark b.txt
+		"r /tmp/restore",
+		"q",
+	}, "\n")
+
+	h := browse.NewHandler(restic.NewService(fake))
+	err := h.Handle(context.Background(), &browse.Command{
+		Repo: &entity.Repository{Name: "local", Path: "/repo"},
+		In:   strings.NewReader(input),
+		Out:  &out,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, fake.restores, 1)
+	assert.Equal(t, []string{
+		"restore",
+		"--target", "/tmp/restore",
+		"-r", "/repo",
+		"--password-command", "",
+		"--include", `/data/a\[1].txt`,
+		"--include", "/data/sub/c.txt",
+		"2222222222",
+	}, fake.restores[0])
+
+	assert.Contains(t, out.String(), "22222222:/data> ")
+	assert.Contains(t, out.String(), "sub/")
+	assert.Contains(t, out.String(), "2.0 KiB")
+	assert.Contains(t, out.String(), "Restored 2 marked entries to /tmp/restore")
+}
+
+func TestBrowseInvalidInput(t *testing.T) {
+	var out bytes.Buffer
+
+	h := browse.NewHandler(restic.NewService(&fakeRestic{}))
+	err := h.Handle(context.Background(), &browse.Command{
+		Repo: &entity.Repository{Name: "local", Path: "/repo"},
+		In:   strings.NewReader("7\n1\ncd /nowhere\nr /tmp/x\n"),
+		Out:  &out,
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, out.String(), "Error: invalid selection")
+	assert.Contains(t, out.String(), "Error: /nowhere is not a directory")
+	assert.Contains(t, out.String(), "Error: no entries marked")
+}
@@ -0,0 +1,78 @@
+package browse
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+const rootDir = "/"
+
+// tree indexes the nodes of a snapshot by their parent directory.
+type tree struct {
+	nodes    map[string]restic.Node
+	children map[string][]restic.Node
+}
+
+func newTree(nodes []restic.Node) *tree {
+	t := &tree{
+		nodes:    make(map[string]restic.Node, len(nodes)),
+		children: make(map[string][]restic.Node),
+	}
+
+	for _, n := range nodes {
+		if n.Path == rootDir {
+			continue
+		}
+		t.nodes[n.Path] = n
+		dir := path.Dir(n.Path)
+		t.children[dir] = append(t.children[dir], n)
+	}
+
+	for _, c := range t.children {
+		slices.SortFunc(c, func(a, b restic.Node) int {
+			if isDir(a) != isDir(b) {
+				if isDir(a) {
+					return -1
+				}
+				return 1
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	return t
+}
+
+// list returns the entries of a directory, directories first.
+func (t *tree) list(dir string) []restic.Node {
+	return t.children[dir]
+}
+
+// isDir reports whether p is a directory of the snapshot.
+func (t *tree) isDir(p string) bool {
+	if p == rootDir {
+		return true
+	}
+	n, ok := t.nodes[p]
+	return ok && isDir(n)
+}
+
+// start returns the directory to open first: the deepest directory
+// reached from the root by following single subdirectories.
+func (t *tree) start() string {
+	dir := rootDir
+	for {
+		entries := t.list(dir)
+		if len(entries) != 1 || !isDir(entries[0]) {
+			return dir
+		}
+		dir = entries[0].Path
+	}
+}
+
+func isDir(n restic.Node) bool {
+	return n.Type == "dir"
+}
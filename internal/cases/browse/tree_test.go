@@ -0,0 +1,34 @@
+package browse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+func TestTree(t *testing.T) {
+	tr := newTree([]restic.Node{
+		{Name: "home", Type: "dir", Path: "/home"},
+		{Name: "user", Type: "dir", Path: "/home/user"},
+		{Name: "notes.txt", Type: "file", Path: "/home/user/notes.txt"},
+		{Name: "docs", Type: "dir", Path: "/home/user/docs"},
+		{Name: "a.txt", Type: "file", Path: "/home/user/docs/a.txt"},
+	})
+
+	assert.Equal(t, "/home/user", tr.start())
+	assert.Equal(t, []string{"docs", "notes.txt"}, names(tr.list("/home/user")))
+	assert.True(t, tr.isDir("/"))
+	assert.True(t, tr.isDir("/home/user/docs"))
+	assert.False(t, tr.isDir("/home/user/notes.txt"))
+	assert.False(t, tr.isDir("/missing"))
+}
+
+func names(nodes []restic.Node) []string {
+	result := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, n.Name)
+	}
+	return result
+}
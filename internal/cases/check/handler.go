@@ -2,50 +2,39 @@ package check
 
 import (
 	"context"
+	"io"
 
+	"github.com/alexander-kolodka/crestic/internal/cases/multirepo"
 	"github.com/alexander-kolodka/crestic/internal/entity"
-	"github.com/alexander-kolodka/crestic/internal/logger"
 	"github.com/alexander-kolodka/crestic/internal/restic"
 )
 
 type Command struct {
 	Repos []*entity.Repository
+	Out   io.Writer
 }
 
 type Handler struct {
 	restic *restic.Service
+	hc     multirepo.HealthChecks
 }
 
-func NewHandler(restic *restic.Service) *Handler {
+func NewHandler(restic *restic.Service, hc multirepo.HealthChecks) *Handler {
 	return &Handler{
 		restic: restic,
+		hc:     hc,
 	}
 }
 
 func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
-	for _, repo := range cmd.Repos {
-		repoCtx := logger.WithRepoFields(ctx, repo)
-		err := h.checkRepo(repoCtx, repo)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return multirepo.Run(ctx, cmd.Repos, h.hc, cmd.Out, h.checkRepo)
 }
 
 func (h *Handler) checkRepo(ctx context.Context, r *entity.Repository) error {
-	isRepoInitialized, err := h.restic.IsRepoInitialized(ctx, r)
+	err := h.restic.EnsureInitialized(ctx, r)
 	if err != nil {
 		return err
 	}
 
-	if !isRepoInitialized {
-		err = h.restic.Init(ctx, r)
-		if err != nil {
-			return err
-		}
-	}
-
-	return h.restic.Check(ctx, r)
+	return h.restic.Check(ctx, r, nil)
 }
@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+// minSnapshotIDLen is the length of restic short snapshot IDs.
+// Shorter numeric references are treated as offsets from the latest snapshot.
+const minSnapshotIDLen = 8
+
+type Command struct {
+	Query entity.SnapshotQuery
+	From  string // Snapshot ID or offset from the latest snapshot; defaults to the snapshot before To
+	To    string // Snapshot ID or offset from the latest snapshot; defaults to the latest snapshot
+	Files bool   // Include the list of changed paths
+	JSON  bool
+	Out   io.Writer
+}
+
+// Result is the difference between two snapshots of a job or repository.
+type Result struct {
+	restic.Diff
+
+	Repository string      `json:"repository"`
+	Job        string      `json:"job,omitempty"`
+	From       SnapshotRef `json:"from"`
+	To         SnapshotRef `json:"to"`
+}
+
+// SnapshotRef identifies a compared snapshot.
+type SnapshotRef struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+type Handler struct {
+	restic *restic.Service
+}
+
+func NewHandler(restic *restic.Service) *Handler {
+	return &Handler{
+		restic: restic,
+	}
+}
+
+// Handle compares two snapshots selected by the query and prints a summary of the changes.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	q := cmd.Query
+	ctx = logger.WithRepoFields(ctx, q.Repo)
+
+	snapshots, err := h.restic.Snapshots(ctx, q.Repo, q.Filter)
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(snapshots, func(a, b restic.Snapshot) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	from, to, err := resolve(snapshots, cmd.From, cmd.To)
+	if err != nil {
+		return err
+	}
+
+	diff, err := h.restic.Diff(ctx, q.Repo, from.ID, to.ID)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Files {
+		diff.Changes = nil
+	}
+
+	result := Result{
+		Diff:       *diff,
+		Repository: q.Repo.Name,
+		Job:        q.Job,
+		From:       SnapshotRef{ID: from.ShortID, Time: from.Time},
+		To:         SnapshotRef{ID: to.ShortID, Time: to.Time},
+	}
+
+	if cmd.JSON {
+		return render.JSON(cmd.Out, result)
+	}
+
+	return printSummary(cmd.Out, result)
+}
+
+// resolve picks the compared snapshots from the list ordered oldest first.
+func resolve(snapshots []restic.Snapshot, fromRef, toRef string) (restic.Snapshot, restic.Snapshot, error) {
+	if len(snapshots) == 0 {
+		return restic.Snapshot{}, restic.Snapshot{}, errors.New("no snapshots found")
+	}
+
+	to := len(snapshots) - 1
+	if toRef != "" {
+		var err error
+		to, err = find(snapshots, toRef)
+		if err != nil {
+			return restic.Snapshot{}, restic.Snapshot{}, err
+		}
+	}
+
+	from := to - 1
+	if fromRef != "" {
+		var err error
+		from, err = find(snapshots, fromRef)
+		if err != nil {
+			return restic.Snapshot{}, restic.Snapshot{}, err
+		}
+	}
+
+	if from < 0 {
+		return restic.Snapshot{}, restic.Snapshot{}, fmt.Errorf(
+			"no snapshot before %s to compare with", snapshots[to].ShortID,
+		)
+	}
+
+	return snapshots[from], snapshots[to], nil
+}
+
+// find returns the index of the snapshot referenced by an ID prefix
+// or by a number of snapshots before the latest one.
+func find(snapshots []restic.Snapshot, ref string) (int, error) {
+	n, err := strconv.Atoi(ref)
+	if err == nil && n >= 0 && len(ref) < minSnapshotIDLen {
+		i := len(snapshots) - 1 - n
+		if i < 0 {
+			return 0, fmt.Errorf("snapshot %d requested, but only %d snapshots exist", n, len(snapshots))
+		}
+		return i, nil
+	}
+
+	i := slices.IndexFunc(snapshots, func(s restic.Snapshot) bool {
+		return strings.HasPrefix(s.ID, ref)
+	})
+	if i < 0 {
+		return 0, fmt.Errorf("snapshot %s not found", ref)
+	}
+
+	return i, nil
+}
+
+func printSummary(w io.Writer, r Result) error {
+	t := render.NewTable(w, "Repository:", r.Repository)
+	if r.Job != "" {
+		t.Row("Job:", r.Job)
+	}
+	t.Row("From:", r.From.ID+"  "+render.Time(r.From.Time))
+	t.Row("To:", r.To.ID+"  "+render.Time(r.To.Time))
+	t.Row("", "")
+	t.Row("Added:", fmt.Sprintf("%d files, %d dirs, %s", r.Added.Files, r.Added.Dirs, render.Bytes(r.Added.Bytes)))
+	t.Row("Removed:", fmt.Sprintf("%d files, %d dirs, %s", r.Removed.Files, r.Removed.Dirs, render.Bytes(r.Removed.Bytes)))
+	t.Row("Modified:", fmt.Sprintf("%d files", r.ChangedFiles))
+	t.Row("Net:", netBytes(r.Added.Bytes, r.Removed.Bytes))
+
+	err := t.Flush()
+	if err != nil {
+		return err
+	}
+
+	if len(r.Changes) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(w)
+	for _, c := range r.Changes {
+		_, _ = fmt.Fprintf(w, "%-2s %s\n", c.Modifier, c.Path)
+	}
+
+	return nil
+}
+
+func netBytes(added, removed uint64) string {
+	if added >= removed {
+		return "+" + render.Bytes(added-removed)
+	}
+	return "-" + render.Bytes(removed-added)
+}
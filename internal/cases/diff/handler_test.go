@@ -0,0 +1,49 @@
+package diff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+func TestResolve(t *testing.T) {
+	snapshots := []restic.Snapshot{
+		{ID: "11111111aa", ShortID: "11111111", Time: time.Unix(1, 0)},
+		{ID: "22222222bb", ShortID: "22222222", Time: time.Unix(2, 0)},
+		{ID: "33333333cc", ShortID: "33333333", Time: time.Unix(3, 0)},
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom string
+		wantTo   string
+		wantErr  string
+	}{
+		{name: "last backup by default", wantFrom: "22222222", wantTo: "33333333"},
+		{name: "offsets from latest", from: "2", to: "1", wantFrom: "11111111", wantTo: "22222222"},
+		{name: "snapshot IDs", from: "11111111", to: "33333333cc", wantFrom: "11111111", wantTo: "33333333"},
+		{name: "previous of given snapshot", to: "22222222", wantFrom: "11111111", wantTo: "22222222"},
+		{name: "nothing before first", to: "2", wantErr: "no snapshot before 11111111"},
+		{name: "offset out of range", from: "3", wantErr: "only 3 snapshots exist"},
+		{name: "unknown ID", to: "deadbeef", wantErr: "snapshot deadbeef not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := resolve(snapshots, tt.from, tt.to)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrom, from.ShortID)
+			assert.Equal(t, tt.wantTo, to.ShortID)
+		})
+	}
+}
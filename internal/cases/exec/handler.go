@@ -1,19 +1,46 @@
 package exec
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
 	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
 )
 
 type Command struct {
-	Repos []*entity.Repository
-	Cmd   string
-	Args  []string
+	Repos     []*entity.Repository
+	Cmd       string
+	Args      []string
+	Parallel  int  // Number of repositories processed at once; values below 2 run sequentially
+	KeepGoing bool // Continue with other repositories after a failure
+	Out       io.Writer
 }
 
+// result is the outcome of the command in a single repository.
+type result struct {
+	repo     string
+	status   string
+	exitCode int
+	duration time.Duration
+	err      error
+}
+
+const (
+	statusOK      = "ok"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
 type Handler struct {
 	restic *restic.Service
 }
@@ -24,19 +51,122 @@ func NewHandler(restic *restic.Service) *Handler {
 	}
 }
 
+// Handle runs the command in every repository. Unless KeepGoing is set, repositories
+// that haven't started yet are skipped after the first failure; running ones are not interrupted.
+// In parallel mode the output of each repository is printed as a whole once it finishes.
+// A summary is printed if more than one repository is involved.
 func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
-	for _, repo := range cmd.Repos {
-		repoCtx := logger.WithRepoFields(ctx, repo)
-		repoCtx = logger.FromContext(repoCtx).With().
-			Str("cmd", cmd.Cmd).
-			Strs("args", cmd.Args).
-			Logger().WithContext(repoCtx)
-
-		err := h.restic.Exec(repoCtx, repo, cmd.Cmd, cmd.Args)
+	parallel := min(max(cmd.Parallel, 1), len(cmd.Repos))
+
+	results := make([]result, len(cmd.Repos))
+	sem := make(chan struct{}, parallel)
+	var failed atomic.Bool
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, repo := range cmd.Repos {
+		sem <- struct{}{}
+		if failed.Load() && !cmd.KeepGoing {
+			<-sem
+			results[i] = result{repo: repo.Name, status: statusSkipped}
+			continue
+		}
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			results[i] = h.exec(ctx, repo, cmd, parallel > 1, &outMu)
+			if results[i].err != nil {
+				failed.Store(true)
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(results) > 1 {
+		err := printSummary(cmd.Out, results)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	var errs []error
+	for _, r := range results {
+		errs = append(errs, r.err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *Handler) exec(
+	ctx context.Context,
+	repo *entity.Repository,
+	cmd *Command,
+	grouped bool,
+	outMu *sync.Mutex,
+) result {
+	repoCtx := logger.WithRepoFields(ctx, repo)
+	repoCtx = logger.FromContext(repoCtx).With().
+		Str("cmd", cmd.Cmd).
+		Strs("args", cmd.Args).
+		Logger().WithContext(repoCtx)
+
+	var out syncBuffer
+	if grouped {
+		repoCtx = shell.WithOutput(repoCtx, &out)
+	}
+
+	start := time.Now()
+	exitCode, err := h.restic.Exec(repoCtx, repo, cmd.Cmd, cmd.Args)
+	res := result{
+		repo:     repo.Name,
+		status:   statusOK,
+		exitCode: exitCode,
+		duration: time.Since(start),
+		err:      err,
+	}
+
+	if err != nil {
+		res.status = statusFailed
+	}
+
+	if grouped {
+		outMu.Lock()
+		defer outMu.Unlock()
+
+		_, _ = logger.NewShellWriter(logger.WithSource(repoCtx, "restic")).Write(out.Bytes())
+	}
+
+	return res
+}
+
+func printSummary(w io.Writer, results []result) error {
+	t := render.NewTable(w, "REPOSITORY", "STATUS", "EXIT CODE", "DURATION")
+	for _, r := range results {
+		exitCode, duration := strconv.Itoa(r.exitCode), render.Duration(r.duration)
+		if r.status == statusSkipped {
+			exitCode, duration = "-", "-"
+		}
+
+		t.Row(r.repo, r.status, exitCode, duration)
+	}
+	return t.Flush()
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes of stdout and stderr.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
 }
@@ -0,0 +1,91 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/exec"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic fails for repositories with path "broken".
+type fakeRestic struct {
+	mu    sync.Mutex
+	repos []string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	path := args[slices.Index(args, "-r")+1]
+
+	f.mu.Lock()
+	f.repos = append(f.repos, path)
+	f.mu.Unlock()
+
+	if path == "broken" {
+		return &shell.Result{ExitCode: 12, Error: errors.New("wrong password")}
+	}
+	return &shell.Result{}
+}
+
+func repos(paths ...string) []*entity.Repository {
+	result := make([]*entity.Repository, 0, len(paths))
+	for _, p := range paths {
+		result = append(result, &entity.Repository{Name: p, Path: p})
+	}
+	return result
+}
+
+func TestExecStopsAtFirstFailure(t *testing.T) {
+	fake := &fakeRestic{}
+	var out bytes.Buffer
+
+	err := exec.NewHandler(restic.NewService(fake)).Handle(context.Background(), &exec.Command{
+		Repos: repos("a", "broken", "c"),
+		Cmd:   "snapshots",
+		Out:   &out,
+	})
+	require.ErrorContains(t, err, "repository broken")
+
+	assert.Equal(t, []string{"a", "broken"}, fake.repos)
+	assert.Regexp(t, `broken\s+failed\s+12`, out.String())
+	assert.Regexp(t, `c\s+skipped\s+-\s+-`, out.String())
+}
+
+func TestExecKeepGoingInParallel(t *testing.T) {
+	fake := &fakeRestic{}
+	var out bytes.Buffer
+
+	err := exec.NewHandler(restic.NewService(fake)).Handle(context.Background(), &exec.Command{
+		Repos:     repos("a", "broken", "c"),
+		Cmd:       "snapshots",
+		Parallel:  3,
+		KeepGoing: true,
+		Out:       &out,
+	})
+	require.ErrorContains(t, err, "repository broken")
+
+	assert.ElementsMatch(t, []string{"a", "broken", "c"}, fake.repos)
+	assert.Regexp(t, `a\s+ok\s+0`, out.String())
+	assert.Regexp(t, `c\s+ok\s+0`, out.String())
+}
+
+func TestExecSingleRepoHasNoSummary(t *testing.T) {
+	var out bytes.Buffer
+
+	err := exec.NewHandler(restic.NewService(&fakeRestic{})).Handle(context.Background(), &exec.Command{
+		Repos: repos("a"),
+		Cmd:   "stats",
+		Out:   &out,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+}
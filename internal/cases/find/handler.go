@@ -0,0 +1,233 @@
+package find
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+type Command struct {
+	Queries  []entity.SnapshotQuery
+	Patterns []string
+	JSON     bool
+	Out      io.Writer
+}
+
+// Version is a distinct version of a matching file, identified by path, size and mtime.
+type Version struct {
+	Path      string     `json:"path"`
+	Type      string     `json:"type"`
+	Size      uint64     `json:"size"`
+	MTime     time.Time  `json:"mtime"`
+	Locations []Location `json:"locations"`
+}
+
+// Location lists the snapshots of a repository containing a file version.
+type Location struct {
+	Repository string    `json:"repository"`
+	Snapshots  []string  `json:"snapshots"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// hit is a single match of a pattern in a snapshot.
+type hit struct {
+	repo     string
+	node     restic.Node
+	snapshot restic.Snapshot
+}
+
+type Handler struct {
+	restic *restic.Service
+}
+
+func NewHandler(restic *restic.Service) *Handler {
+	return &Handler{
+		restic: restic,
+	}
+}
+
+// Handle searches all queries concurrently and prints the matching file versions
+// merged into a single timeline. A failing repository doesn't prevent searching the others;
+// its error is returned at the end.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	results := make([][]hit, len(cmd.Queries))
+	errs := make([]error, len(cmd.Queries))
+
+	var wg sync.WaitGroup
+	for i, q := range cmd.Queries {
+		wg.Go(func() {
+			results[i], errs[i] = h.find(ctx, q, cmd.Patterns)
+		})
+	}
+	wg.Wait()
+
+	versions := merge(slices.Concat(results...))
+
+	var err error
+	if cmd.JSON {
+		err = render.JSON(cmd.Out, versions)
+	} else {
+		err = printTable(cmd.Out, versions)
+	}
+
+	return errors.Join(append(errs, err)...)
+}
+
+func (h *Handler) find(ctx context.Context, q entity.SnapshotQuery, patterns []string) ([]hit, error) {
+	ctx = logger.WithRepoFields(ctx, q.Repo)
+
+	snapshots, err := h.restic.Snapshots(ctx, q.Repo, q.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]restic.Snapshot, len(snapshots))
+	for _, s := range snapshots {
+		byID[s.ID] = s
+		byID[s.ShortID] = s
+	}
+
+	found, err := h.restic.Find(ctx, q.Repo, q.Filter, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []hit
+	for _, f := range found {
+		snapshot, ok := byID[f.Snapshot]
+		if !ok {
+			snapshot = restic.Snapshot{ID: f.Snapshot, ShortID: shortID(f.Snapshot)}
+		}
+
+		for _, n := range f.Matches {
+			hits = append(hits, hit{repo: q.Repo.Name, node: n, snapshot: snapshot})
+		}
+	}
+
+	return hits, nil
+}
+
+// merge groups hits into file versions, ordered by path and modification time.
+// A snapshot found by several queries of the same repository is counted once.
+func merge(hits []hit) []Version {
+	type versionKey struct {
+		path  string
+		size  uint64
+		mtime int64
+	}
+
+	type hitKey struct {
+		repo     string
+		snapshot string
+		path     string
+	}
+
+	index := map[versionKey]*Version{}
+	seen := map[hitKey]bool{}
+	for _, h := range hits {
+		key := versionKey{path: h.node.Path, size: h.node.Size, mtime: h.node.MTime.UnixNano()}
+		v, ok := index[key]
+		if !ok {
+			v = &Version{Path: h.node.Path, Type: h.node.Type, Size: h.node.Size, MTime: h.node.MTime}
+			index[key] = v
+		}
+
+		hk := hitKey{repo: h.repo, snapshot: h.snapshot.ID, path: h.node.Path}
+		if seen[hk] {
+			continue
+		}
+		seen[hk] = true
+
+		v.add(h)
+	}
+
+	versions := make([]Version, 0, len(index))
+	for _, v := range index {
+		slices.SortFunc(v.Locations, func(a, b Location) int {
+			return cmp.Or(a.FirstSeen.Compare(b.FirstSeen), cmp.Compare(a.Repository, b.Repository))
+		})
+		versions = append(versions, *v)
+	}
+
+	slices.SortFunc(versions, func(a, b Version) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			a.MTime.Compare(b.MTime),
+			cmp.Compare(a.Size, b.Size),
+		)
+	})
+
+	return versions
+}
+
+func (v *Version) add(h hit) {
+	i := slices.IndexFunc(v.Locations, func(l Location) bool { return l.Repository == h.repo })
+	if i < 0 {
+		v.Locations = append(v.Locations, Location{
+			Repository: h.repo,
+			FirstSeen:  h.snapshot.Time,
+			LastSeen:   h.snapshot.Time,
+		})
+		i = len(v.Locations) - 1
+	}
+
+	l := &v.Locations[i]
+	l.Snapshots = append(l.Snapshots, h.snapshot.ShortID)
+	if h.snapshot.Time.Before(l.FirstSeen) {
+		l.FirstSeen = h.snapshot.Time
+	}
+	if h.snapshot.Time.After(l.LastSeen) {
+		l.LastSeen = h.snapshot.Time
+	}
+}
+
+func printTable(w io.Writer, versions []Version) error {
+	t := render.NewTable(w, "PATH", "MODIFIED", "SIZE", "REPOSITORY", "SNAPSHOTS", "FIRST SEEN", "LAST SEEN")
+
+	prevPath := ""
+	for _, v := range versions {
+		path, size := v.Path, render.Bytes(v.Size)
+		if path == prevPath {
+			path = ""
+		}
+		if v.Type == "dir" {
+			size = "-"
+		}
+		prevPath = v.Path
+
+		for i, l := range v.Locations {
+			modified := render.Time(v.MTime)
+			if i > 0 {
+				path, modified, size = "", "", ""
+			}
+
+			t.Row(
+				path,
+				modified,
+				size,
+				l.Repository,
+				strconv.Itoa(len(l.Snapshots)),
+				render.Time(l.FirstSeen),
+				render.Time(l.LastSeen),
+			)
+		}
+	}
+
+	return t.Flush()
+}
+
+func shortID(id string) string {
+	const shortIDLen = 8
+	return id[:min(len(id), shortIDLen)]
+}
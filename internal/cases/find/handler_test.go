@@ -0,0 +1,44 @@
+package find
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+func TestMerge(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	snap := func(id string, d int) restic.Snapshot { return restic.Snapshot{ID: id, ShortID: id, Time: day(d)} }
+
+	v1 := restic.Node{Path: "/data/a.txt", Type: "file", Size: 10, MTime: day(1)}
+	v2 := restic.Node{Path: "/data/a.txt", Type: "file", Size: 12, MTime: day(3)}
+	other := restic.Node{Path: "/data/b.txt", Type: "file", Size: 1, MTime: day(1)}
+
+	versions := merge([]hit{
+		{repo: "remote", node: v1, snapshot: snap("r1", 2)},
+		{repo: "local", node: v2, snapshot: snap("l3", 3)},
+		{repo: "local", node: v1, snapshot: snap("l2", 2)},
+		{repo: "local", node: v1, snapshot: snap("l1", 1)},
+		{repo: "local", node: v1, snapshot: snap("l1", 1)}, // found again by another query
+		{repo: "local", node: other, snapshot: snap("l1", 1)},
+	})
+
+	require.Len(t, versions, 3)
+
+	assert.Equal(t, v1.MTime, versions[0].MTime)
+	assert.Equal(t, []Location{
+		{Repository: "local", Snapshots: []string{"l2", "l1"}, FirstSeen: day(1), LastSeen: day(2)},
+		{Repository: "remote", Snapshots: []string{"r1"}, FirstSeen: day(2), LastSeen: day(2)},
+	}, versions[0].Locations)
+
+	assert.Equal(t, uint64(12), versions[1].Size)
+	assert.Equal(t, []Location{
+		{Repository: "local", Snapshots: []string{"l3"}, FirstSeen: day(3), LastSeen: day(3)},
+	}, versions[1].Locations)
+
+	assert.Equal(t, "/data/b.txt", versions[2].Path)
+}
@@ -2,7 +2,9 @@ package forget
 
 import (
 	"context"
+	"io"
 
+	"github.com/alexander-kolodka/crestic/internal/cases/multirepo"
 	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/logger"
 	"github.com/alexander-kolodka/crestic/internal/restic"
@@ -12,15 +14,18 @@ type Command struct {
 	Repos  []*entity.Repository
 	DryRun bool
 	Prune  bool
+	Out    io.Writer
 }
 
 type Handler struct {
 	restic *restic.Service
+	hc     multirepo.HealthChecks
 }
 
-func NewHandler(restic *restic.Service) *Handler {
+func NewHandler(restic *restic.Service, hc multirepo.HealthChecks) *Handler {
 	return &Handler{
 		restic: restic,
+		hc:     hc,
 	}
 }
 
@@ -30,15 +35,9 @@ func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
 		Bool("prune", cmd.Prune).
 		Logger().WithContext(ctx)
 
-	for _, repo := range cmd.Repos {
-		repoCtx := logger.WithRepoFields(ctx, repo)
-		err := h.forget(repoCtx, cmd, repo)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return multirepo.Run(ctx, cmd.Repos, h.hc, cmd.Out, func(ctx context.Context, r *entity.Repository) error {
+		return h.forget(ctx, cmd, r)
+	})
 }
 
 func (h *Handler) forget(ctx context.Context, cmd *Command, r *entity.Repository) error {
@@ -0,0 +1,157 @@
+package freshness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+type Command struct {
+	Jobs   []entity.Job
+	MaxAge time.Duration // Overrides max_age of every job if set
+	Host   string        // Overrides the host filter of every job if set
+}
+
+type HealthChecks interface {
+	Start(ctx context.Context, rid string, j *healthchecks.JobsList) error
+	Success(ctx context.Context, rid string, r *entity.JobResults) error
+	Fail(ctx context.Context, rid string, r *entity.JobResults) error
+}
+
+type Handler struct {
+	restic *restic.Service
+	hc     HealthChecks
+}
+
+func NewHandler(restic *restic.Service, hc HealthChecks) *Handler {
+	return &Handler{
+		restic: restic,
+		hc:     hc,
+	}
+}
+
+// Handle checks that the latest snapshot of every job is younger than its max_age.
+// For jobs with sources, the latest snapshot of every database is checked.
+// Jobs without max_age are skipped unless a MaxAge override is given.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	var checks []check
+	for _, j := range cmd.Jobs {
+		jobChecks := newChecks(j, cmd)
+		if len(jobChecks) == 0 {
+			log := logger.FromContext(ctx)
+			log.Debug().Str("job", j.GetName()).Msg("Skip job without max_age")
+		}
+		checks = append(checks, jobChecks...)
+	}
+
+	if len(checks) == 0 {
+		return errors.New("no jobs with max_age to verify")
+	}
+
+	rid := uuid.NewString()
+	_ = h.hc.Start(ctx, rid, healthchecks.NewJobsList(lo.Map(checks, func(c check, _ int) string {
+		return c.job
+	})))
+
+	now := time.Now()
+	jobResults := entity.NewJobResults()
+	for _, c := range checks {
+		start := time.Now()
+		err := h.verify(ctx, c, now)
+		jobResults.Add(c.job, time.Since(start), err)
+	}
+
+	if jobResults.HasErrors() {
+		_ = h.hc.Fail(ctx, rid, jobResults)
+		return errors.New(jobResults.ErrorMsg())
+	}
+
+	_ = h.hc.Success(ctx, rid, jobResults)
+	return nil
+}
+
+// check is a single job freshness requirement.
+// Jobs with sources have a check per database, so a stale database isn't hidden by the others.
+type check struct {
+	job    string
+	repo   *entity.Repository
+	filter entity.SnapshotFilter
+	maxAge time.Duration
+}
+
+func newChecks(j entity.Job, cmd *Command) []check {
+	queries, ok := entity.SnapshotQueries(j)
+	if !ok {
+		return nil
+	}
+
+	var maxAge time.Duration
+	switch v := j.(type) {
+	case entity.BackupJob:
+		maxAge = v.MaxAge
+	case entity.CopyJob:
+		maxAge = v.MaxAge
+	default:
+	}
+
+	if cmd.MaxAge > 0 {
+		maxAge = cmd.MaxAge
+	}
+
+	if maxAge <= 0 {
+		return nil
+	}
+
+	return lo.Map(queries, func(q entity.SnapshotQuery, _ int) check {
+		if cmd.Host != "" {
+			q.Filter.Host = cmd.Host
+		}
+		return check{job: q.Job, repo: q.Repo, filter: q.Filter, maxAge: maxAge}
+	})
+}
+
+func (h *Handler) verify(ctx context.Context, c check, now time.Time) error {
+	ctx = logger.WithRepoFields(ctx, c.repo)
+	log := logger.FromContext(ctx).With().
+		Str("job", c.job).
+		Stringer("max_age", c.maxAge).
+		Logger()
+
+	snapshots, err := h.restic.Snapshots(ctx, c.repo, c.filter, "latest")
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		log.Error().Msg("No snapshots found")
+		return fmt.Errorf("no snapshots found in repository %s", c.repo.Name)
+	}
+
+	latest := lo.MaxBy(snapshots, func(a, b restic.Snapshot) bool {
+		return a.Time.After(b.Time)
+	})
+
+	age := now.Sub(latest.Time).Round(time.Second)
+	log = log.With().
+		Str("snapshot", latest.ShortID).
+		Time("snapshot_time", latest.Time).
+		Stringer("age", age).
+		Logger()
+
+	if age > c.maxAge {
+		log.Error().Msg("Latest snapshot is too old")
+		return fmt.Errorf("latest snapshot %s is %s old, max age is %s", latest.ShortID, age, c.maxAge)
+	}
+
+	log.Info().Msg("Latest snapshot is fresh")
+	return nil
+}
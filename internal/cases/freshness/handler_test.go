@@ -0,0 +1,74 @@
+package freshness_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/freshness"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic returns the latest snapshot of the database selected by the db:<name> tag,
+// created age[name] ago, and records the snapshot filters.
+type fakeRestic struct {
+	age     map[string]time.Duration
+	filters []string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	tags := args[slices.Index(args, "--tag")+1]
+	f.filters = append(f.filters, strings.Join(args[slices.Index(args, "--path"):], " "))
+
+	_, db, _ := strings.Cut(tags, "db:")
+	return &shell.Result{Stdout: fmt.Sprintf(
+		`[{"short_id":%q,"time":%q}]`,
+		db,
+		time.Now().Add(-f.age[db]).Format(time.RFC3339),
+	)}
+}
+
+func dumpJob() entity.BackupJob {
+	return entity.BackupJob{
+		Name:   "db",
+		To:     &entity.Repository{Name: "nas", Path: "/nas", PasswordCMD: "pass"},
+		MaxAge: 24 * time.Hour,
+		Dumps: []entity.Dump{
+			{Kind: entity.DumpPostgres, Database: "app", Source: entity.CommandSource{Filename: "app.dump"}},
+			{Kind: entity.DumpPostgres, Database: "crm", Source: entity.CommandSource{Filename: "crm.dump"}},
+		},
+	}
+}
+
+func TestFreshnessChecksEveryDatabase(t *testing.T) {
+	fake := &fakeRestic{age: map[string]time.Duration{"app": time.Hour, "crm": time.Hour}}
+
+	err := freshness.NewHandler(restic.NewService(fake), &healthchecks.Dummy{}).Handle(context.Background(),
+		&freshness.Command{Jobs: []entity.Job{dumpJob()}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"--path /app.dump --tag postgres,db:app latest",
+		"--path /crm.dump --tag postgres,db:crm latest",
+	}, fake.filters)
+}
+
+func TestFreshnessFailsForStaleDatabase(t *testing.T) {
+	fake := &fakeRestic{age: map[string]time.Duration{"app": time.Hour, "crm": 72 * time.Hour}}
+
+	err := freshness.NewHandler(restic.NewService(fake), &healthchecks.Dummy{}).Handle(context.Background(),
+		&freshness.Command{Jobs: []entity.Job{dumpJob()}})
+	require.ErrorContains(t, err, "db/crm elapsed")
+	require.ErrorContains(t, err, "latest snapshot crm is 72h0m")
+
+	assert.NotContains(t, err.Error(), "db/app")
+}
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/runhistory"
+)
+
+type Command struct {
+	Job   string
+	Since time.Time
+	JSON  bool
+	Out   io.Writer
+}
+
+type Handler struct {
+	store *runhistory.Store
+}
+
+func NewHandler(store *runhistory.Store) *Handler {
+	return &Handler{
+		store: store,
+	}
+}
+
+func (h *Handler) Handle(_ context.Context, cmd *Command) error {
+	runs, err := h.store.List(runhistory.Filter{
+		Job:   cmd.Job,
+		Since: cmd.Since,
+	})
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSON {
+		return render.JSON(cmd.Out, runs)
+	}
+
+	return printTable(cmd.Out, runs)
+}
+
+func printTable(w io.Writer, runs []runhistory.Run) error {
+	t := render.NewTable(w, "STARTED", "JOB", "TYPE", "STATUS", "DURATION", "SNAPSHOT", "PROCESSED", "ADDED", "DETAILS")
+
+	for _, run := range runs {
+		for _, j := range run.Jobs {
+			processed, added := "-", "-"
+			if j.Stats != nil {
+				processed = render.Bytes(j.Stats.TotalBytesProcessed)
+				added = render.Bytes(j.Stats.DataAdded)
+			}
+
+			details := j.Error
+			if details == "" {
+				details = j.SkippedReason
+			}
+
+			t.Row(
+				render.Time(j.Start),
+				j.Name,
+				j.Type,
+				string(j.Status),
+				render.Duration(j.End.Sub(j.Start)),
+				render.OrDash(shortID(j.SnapshotID)),
+				processed,
+				added,
+				render.OrDash(firstLine(details)),
+			)
+		}
+	}
+
+	return t.Flush()
+}
+
+func shortID(id string) string {
+	const shortLen = 8
+	if len(id) > shortLen {
+		return id[:shortLen]
+	}
+	return id
+}
+
+func firstLine(s string) string {
+	for i, r := range s {
+		if r == '\n' {
+			return s[:i]
+		}
+	}
+	return s
+}
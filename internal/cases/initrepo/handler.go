@@ -0,0 +1,69 @@
+package initrepo
+
+import (
+	"context"
+	"io"
+	"slices"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/multirepo"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+type Command struct {
+	Repos []*entity.Repository
+	Out   io.Writer
+}
+
+type Handler struct {
+	restic *restic.Service
+	hc     multirepo.HealthChecks
+}
+
+func NewHandler(restic *restic.Service, hc multirepo.HealthChecks) *Handler {
+	return &Handler{
+		restic: restic,
+		hc:     hc,
+	}
+}
+
+// Handle initializes the repositories that don't exist yet, regardless of their auto_init setting.
+// Repositories copying chunker parameters from another one are initialized after it,
+// so their source is created first when both are selected.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	repos := slices.Clone(cmd.Repos)
+	slices.SortStableFunc(repos, func(a, b *entity.Repository) int {
+		return chunkerParamsDepth(a) - chunkerParamsDepth(b)
+	})
+
+	return multirepo.Run(ctx, repos, h.hc, cmd.Out, h.initRepo)
+}
+
+func (h *Handler) initRepo(ctx context.Context, r *entity.Repository) error {
+	isRepoInitialized, err := h.restic.IsRepoInitialized(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if isRepoInitialized {
+		log := logger.FromContext(ctx)
+		log.Info().Msg("Repository is already initialized")
+		return nil
+	}
+
+	return h.restic.Init(ctx, r)
+}
+
+// chunkerParamsDepth returns the number of repositories r copies chunker parameters through.
+// A cycle, which can't be initialized anyway, stops at the first repeated repository.
+func chunkerParamsDepth(r *entity.Repository) int {
+	seen := map[*entity.Repository]bool{r: true}
+	depth := 0
+	for src := r.InitOptions.CopyChunkerParamsFrom; src != nil && !seen[src]; {
+		seen[src] = true
+		depth++
+		src = src.InitOptions.CopyChunkerParamsFrom
+	}
+	return depth
+}
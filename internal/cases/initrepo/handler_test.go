@@ -0,0 +1,83 @@
+package initrepo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/initrepo"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic reports repositories as initialized once `restic init` ran for them,
+// or if their path is "existing", and records the repositories it initialized.
+type fakeRestic struct {
+	initialized []string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	path := args[slices.Index(args, "-r")+1]
+
+	switch args[0] {
+	case "stats":
+		if path != "existing" && !slices.Contains(f.initialized, path) {
+			return &shell.Result{ExitCode: 10, Error: errors.New("repository does not exist")}
+		}
+	case "init":
+		from := slices.Index(args, "--from-repo")
+		if from >= 0 && !slices.Contains(f.initialized, args[from+1]) {
+			return &shell.Result{ExitCode: 1, Error: errors.New("source repository does not exist")}
+		}
+		f.initialized = append(f.initialized, path)
+	}
+	return &shell.Result{}
+}
+
+func repo(path string) *entity.Repository {
+	return &entity.Repository{Name: path, Path: path, PasswordCMD: "pass"}
+}
+
+func TestInitSkipsExistingRepositories(t *testing.T) {
+	fake := &fakeRestic{}
+
+	err := initrepo.NewHandler(restic.NewService(fake), &healthchecks.Dummy{}).Handle(context.Background(),
+		&initrepo.Command{Repos: []*entity.Repository{repo("existing"), repo("new")}, Out: &bytes.Buffer{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"new"}, fake.initialized)
+}
+
+func TestInitOrdersRepositoriesAfterChunkerParamsSource(t *testing.T) {
+	fake := &fakeRestic{}
+	nas := repo("nas")
+	usb := repo("usb")
+	usb.InitOptions.CopyChunkerParamsFrom = nas
+	offsite := repo("offsite")
+	offsite.InitOptions.CopyChunkerParamsFrom = usb
+
+	err := initrepo.NewHandler(restic.NewService(fake), &healthchecks.Dummy{}).Handle(context.Background(),
+		&initrepo.Command{Repos: []*entity.Repository{offsite, usb, nas}, Out: &bytes.Buffer{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"nas", "usb", "offsite"}, fake.initialized)
+}
+
+func TestInitIgnoresAutoInit(t *testing.T) {
+	fake := &fakeRestic{}
+	usb := repo("usb")
+	usb.AutoInit = false
+
+	err := initrepo.NewHandler(restic.NewService(fake), &healthchecks.Dummy{}).Handle(context.Background(),
+		&initrepo.Command{Repos: []*entity.Repository{usb}, Out: &bytes.Buffer{}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"usb"}, fake.initialized, "crestic init creates repositories with auto_init: false")
+}
@@ -0,0 +1,196 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+// Action is the key management operation to perform.
+type Action string
+
+const (
+	ActionList   Action = "list"
+	ActionAdd    Action = "add"
+	ActionRotate Action = "rotate"
+)
+
+type Command struct {
+	Action         Action
+	Repo           *entity.Repository
+	NewPasswordCMD string // Password command of the new key (add and rotate)
+	JSON           bool
+	Out            io.Writer
+}
+
+// Confirmer asks the user to approve a destructive action.
+type Confirmer interface {
+	Confirm(prompt string) (bool, error)
+}
+
+type Handler struct {
+	restic  *restic.Service
+	confirm Confirmer
+}
+
+func NewHandler(restic *restic.Service, confirm Confirmer) *Handler {
+	return &Handler{
+		restic:  restic,
+		confirm: confirm,
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	ctx = logger.WithRepoFields(ctx, cmd.Repo)
+
+	switch cmd.Action {
+	case ActionList:
+		return h.list(ctx, cmd)
+	case ActionAdd:
+		return h.add(ctx, cmd)
+	case ActionRotate:
+		return h.rotate(ctx, cmd)
+	default:
+		return fmt.Errorf("unknown key action %q", cmd.Action)
+	}
+}
+
+func (h *Handler) list(ctx context.Context, cmd *Command) error {
+	keys, err := h.restic.Keys(ctx, cmd.Repo)
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSON {
+		return render.JSON(cmd.Out, keys)
+	}
+
+	t := render.NewTable(cmd.Out, "ID", "CURRENT", "USER", "HOST", "CREATED")
+	for _, k := range keys {
+		t.Row(k.ID, lo.Ternary(k.Current, "*", ""), render.OrDash(k.UserName), render.OrDash(k.HostName), k.Created)
+	}
+	return t.Flush()
+}
+
+func (h *Handler) add(ctx context.Context, cmd *Command) error {
+	newKey, err := h.addVerifiedKey(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(cmd.Out, "Key %s added and verified. The existing keys remain valid.\n", newKey.ID)
+	return printConfigChange(cmd.Out, cmd.Repo, cmd.NewPasswordCMD)
+}
+
+// rotate replaces the key used by the configured password source with a key for the new password.
+// The old key is only removed after the new one has been verified to open the repository.
+func (h *Handler) rotate(ctx context.Context, cmd *Command) error {
+	keys, err := h.restic.Keys(ctx, cmd.Repo)
+	if err != nil {
+		return err
+	}
+
+	oldKey, ok := lo.Find(keys, func(k restic.Key) bool { return k.Current })
+	if !ok {
+		return fmt.Errorf("repository %s: the key of the configured password was not found", cmd.Repo.Name)
+	}
+
+	ok, err = h.confirm.Confirm(fmt.Sprintf(
+		"Replace key %s of repository %s with a key for the new password? "+
+			"The current password stops working.",
+		oldKey.ID,
+		cmd.Repo.Name,
+	))
+	if err != nil {
+		return fmt.Errorf("confirm key rotation: %w", err)
+	}
+	if !ok {
+		return errors.New("key rotation aborted")
+	}
+
+	newKey, err := h.addVerifiedKey(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	// restic refuses to remove the key that opened the repository, so the new key is used.
+	err = h.restic.RemoveKey(ctx, withPasswordCommand(cmd.Repo, cmd.NewPasswordCMD), oldKey.ID)
+	if err != nil {
+		return fmt.Errorf("new key %s was added, but the old key %s wasn't removed: %w", newKey.ID, oldKey.ID, err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.Out, "Key %s replaced with %s. The old password no longer opens the repository.\n",
+		oldKey.ID, newKey.ID)
+	return printConfigChange(cmd.Out, cmd.Repo, cmd.NewPasswordCMD)
+}
+
+// addVerifiedKey adds a key for the new password and checks that it opens the repository.
+// Nothing is added if the new password already opens the repository.
+func (h *Handler) addVerifiedKey(ctx context.Context, cmd *Command) (restic.Key, error) {
+	if cmd.NewPasswordCMD == "" {
+		return restic.Key{}, errors.New("a password command for the new key is required")
+	}
+
+	// A wrong password is the expected outcome, so the failure isn't logged as an error.
+	keys, err := h.restic.Keys(restic.WithQuietErrors(ctx), withPasswordCommand(cmd.Repo, cmd.NewPasswordCMD))
+	if err == nil {
+		existing, _ := lo.Find(keys, func(k restic.Key) bool { return k.Current })
+		return restic.Key{}, fmt.Errorf("repository %s: the new password already opens the repository (key %s)",
+			cmd.Repo.Name, existing.ID)
+	}
+
+	err = h.restic.AddKey(ctx, cmd.Repo, cmd.NewPasswordCMD)
+	if err != nil {
+		return restic.Key{}, err
+	}
+
+	keys, err = h.restic.Keys(ctx, withPasswordCommand(cmd.Repo, cmd.NewPasswordCMD))
+	if err != nil {
+		return restic.Key{}, fmt.Errorf("verify new key: %w", err)
+	}
+
+	newKey, ok := lo.Find(keys, func(k restic.Key) bool { return k.Current })
+	if !ok {
+		return restic.Key{}, fmt.Errorf("repository %s: the new key doesn't open the repository", cmd.Repo.Name)
+	}
+
+	return newKey, nil
+}
+
+// withPasswordCommand returns a copy of repo using the given password command as its only password source.
+func withPasswordCommand(repo *entity.Repository, passwordCMD string) *entity.Repository {
+	r := *repo
+	r.PasswordCMD = passwordCMD
+	r.PasswordFile = ""
+	r.PasswordEnv = ""
+	r.Password = ""
+	r.CachePassword = false
+	return &r
+}
+
+func printConfigChange(w io.Writer, repo *entity.Repository, passwordCMD string) error {
+	change, err := yaml.Marshal(map[string]any{
+		"repositories": map[string]any{
+			repo.Name: map[string]string{"password_command": passwordCMD},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("encode config change: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nTo use the new key, update the repository in your config:\n\n%s", change)
+	if repo.PasswordCMD == "" {
+		_, _ = fmt.Fprintln(w, "\nAlso remove its password, password_file or password_env setting.")
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package key_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/key"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic simulates the keys of a single repository. Password commands are
+// "echo <password>", and every password has a key with the ID "key-<password>".
+type fakeRestic struct {
+	passwords []string
+	removed   []string
+	breakAdd  bool // key add succeeds without adding a key
+}
+
+func (f *fakeRestic) Run(_ context.Context, service string, args ...string) *shell.Result {
+	if service == "sh" {
+		return &shell.Result{Stdout: strings.TrimPrefix(args[1], "echo ") + "\n"}
+	}
+
+	password := strings.TrimPrefix(args[slices.Index(args, "--password-command")+1], "echo ")
+	if !slices.Contains(f.passwords, password) {
+		return &shell.Result{ExitCode: 12, Error: errors.New("wrong password")}
+	}
+
+	switch args[1] {
+	case "list":
+		keys := make([]restic.Key, 0, len(f.passwords))
+		for _, p := range f.passwords {
+			keys = append(keys, restic.Key{ID: "key-" + p, Current: p == password})
+		}
+		b, _ := json.Marshal(keys)
+		return &shell.Result{Stdout: string(b)}
+	case "add":
+		b, err := os.ReadFile(args[slices.Index(args, "--new-password-file")+1])
+		if err != nil {
+			return &shell.Result{ExitCode: 1, Error: err}
+		}
+		if !f.breakAdd {
+			f.passwords = append(f.passwords, string(b))
+		}
+		return &shell.Result{}
+	case "remove":
+		id := args[len(args)-1]
+		if id == "key-"+password {
+			return &shell.Result{ExitCode: 1, Error: errors.New("refusing to remove key currently used")}
+		}
+		f.removed = append(f.removed, id)
+		f.passwords = slices.DeleteFunc(f.passwords, func(p string) bool { return "key-"+p == id })
+		return &shell.Result{}
+	}
+
+	return &shell.Result{ExitCode: 1, Error: errors.New("unexpected command")}
+}
+
+type confirmer bool
+
+func (c confirmer) Confirm(string) (bool, error) {
+	return bool(c), nil
+}
+
+func repo() *entity.Repository {
+	return &entity.Repository{Name: "nas", Path: "/nas", PasswordCMD: "echo old"}
+}
+
+func TestList(t *testing.T) {
+	fake := &fakeRestic{passwords: []string{"old", "laptop"}}
+	var out bytes.Buffer
+
+	err := key.NewHandler(restic.NewService(fake), confirmer(true)).Handle(context.Background(), &key.Command{
+		Action: key.ActionList,
+		Repo:   repo(),
+		Out:    &out,
+	})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `key-old\s+\*`, out.String())
+	assert.Regexp(t, `key-laptop\s+-`, out.String())
+}
+
+func TestAdd(t *testing.T) {
+	fake := &fakeRestic{passwords: []string{"old"}}
+	var out bytes.Buffer
+
+	err := key.NewHandler(restic.NewService(fake), confirmer(true)).Handle(context.Background(), &key.Command{
+		Action:         key.ActionAdd,
+		Repo:           repo(),
+		NewPasswordCMD: "echo new",
+		Out:            &out,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"old", "new"}, fake.passwords)
+	assert.Contains(t, out.String(), "Key key-new added and verified")
+	assert.Contains(t, out.String(), "repositories:\n    nas:\n        password_command: echo new\n")
+}
+
+func TestRotate(t *testing.T) {
+	fake := &fakeRestic{passwords: []string{"old", "laptop"}}
+	var out bytes.Buffer
+
+	err := key.NewHandler(restic.NewService(fake), confirmer(true)).Handle(context.Background(), &key.Command{
+		Action:         key.ActionRotate,
+		Repo:           repo(),
+		NewPasswordCMD: "echo new",
+		Out:            &out,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"laptop", "new"}, fake.passwords)
+	assert.Equal(t, []string{"key-old"}, fake.removed)
+	assert.Contains(t, out.String(), "Key key-old replaced with key-new")
+}
+
+func TestRotateKeepsOldKeyIfNewKeyDoesNotOpenRepository(t *testing.T) {
+	fake := &fakeRestic{passwords: []string{"old"}, breakAdd: true}
+
+	err := key.NewHandler(restic.NewService(fake), confirmer(true)).Handle(context.Background(), &key.Command{
+		Action:         key.ActionRotate,
+		Repo:           repo(),
+		NewPasswordCMD: "echo new",
+		Out:            &bytes.Buffer{},
+	})
+	require.ErrorContains(t, err, "verify new key")
+
+	assert.Equal(t, []string{"old"}, fake.passwords)
+	assert.Empty(t, fake.removed)
+}
+
+func TestRotateToCurrentPasswordAddsNoKey(t *testing.T) {
+	fake := &fakeRestic{passwords: []string{"old", "laptop"}}
+
+	err := key.NewHandler(restic.NewService(fake), confirmer(true)).Handle(context.Background(), &key.Command{
+		Action:         key.ActionRotate,
+		Repo:           repo(),
+		NewPasswordCMD: "echo old",
+		Out:            &bytes.Buffer{},
+	})
+	require.EqualError(t, err, "repository nas: the new password already opens the repository (key key-old)")
+
+	assert.Equal(t, []string{"old", "laptop"}, fake.passwords)
+	assert.Empty(t, fake.removed)
+}
+
+func TestRotateAborted(t *testing.T) {
+	fake := &fakeRestic{passwords: []string{"old"}}
+
+	err := key.NewHandler(restic.NewService(fake), confirmer(false)).Handle(context.Background(), &key.Command{
+		Action:         key.ActionRotate,
+		Repo:           repo(),
+		NewPasswordCMD: "echo new",
+		Out:            &bytes.Buffer{},
+	})
+	require.EqualError(t, err, "key rotation aborted")
+
+	assert.Equal(t, []string{"old"}, fake.passwords)
+}
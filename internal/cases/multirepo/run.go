@@ -0,0 +1,77 @@
+// Package multirepo runs an operation on several repositories independently.
+package multirepo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+type HealthChecks interface {
+	Start(ctx context.Context, rid string, j *healthchecks.JobsList) error
+	Success(ctx context.Context, rid string, r *entity.JobResults) error
+	Fail(ctx context.Context, rid string, r *entity.JobResults) error
+}
+
+// Run calls fn for every repository. A failing repository doesn't stop the others:
+// outcomes are collected into JobResults keyed by repository name, reported to hc,
+// and all errors are returned at the end.
+// If more than one repository is processed, a summary is written to out.
+func Run(
+	ctx context.Context,
+	repos []*entity.Repository,
+	hc HealthChecks,
+	out io.Writer,
+	fn func(ctx context.Context, repo *entity.Repository) error,
+) error {
+	rid := uuid.NewString()
+	_ = hc.Start(ctx, rid, healthchecks.NewJobsList(lo.Map(repos, func(r *entity.Repository, _ int) string {
+		return r.Name
+	})))
+
+	summary := render.NewTable(out, "REPOSITORY", "STATUS", "DURATION")
+	jobResults := entity.NewJobResults()
+	for _, repo := range repos {
+		ie, interrupted := shell.Interrupted(ctx)
+		if interrupted {
+			jobResults.Interrupt(ie.Error())
+			break
+		}
+
+		start := time.Now()
+		err := fn(logger.WithRepoFields(ctx, repo), repo)
+		elapsed := time.Since(start)
+		jobResults.Add(repo.Name, elapsed, err)
+
+		status := "ok"
+		if err != nil {
+			status = "failed"
+		}
+		summary.Row(repo.Name, status, render.Duration(elapsed))
+	}
+
+	if len(repos) > 1 {
+		_ = summary.Flush()
+	}
+
+	// The results are reported even if the run was interrupted.
+	ctx = context.WithoutCancel(ctx)
+
+	if jobResults.HasErrors() {
+		_ = hc.Fail(ctx, rid, jobResults)
+		return errors.New(jobResults.ErrorMsg())
+	}
+
+	_ = hc.Success(ctx, rid, jobResults)
+	return nil
+}
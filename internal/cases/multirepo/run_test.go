@@ -0,0 +1,79 @@
+package multirepo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/multirepo"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+)
+
+type fakeHC struct {
+	started bool
+	success *entity.JobResults
+	fail    *entity.JobResults
+}
+
+func (f *fakeHC) Start(context.Context, string, *healthchecks.JobsList) error {
+	f.started = true
+	return nil
+}
+
+func (f *fakeHC) Success(_ context.Context, _ string, r *entity.JobResults) error {
+	f.success = r
+	return nil
+}
+
+func (f *fakeHC) Fail(_ context.Context, _ string, r *entity.JobResults) error {
+	f.fail = r
+	return nil
+}
+
+func TestRunContinuesAfterFailure(t *testing.T) {
+	hc := &fakeHC{}
+	var out bytes.Buffer
+	var visited []string
+
+	repos := []*entity.Repository{{Name: "nas"}, {Name: "offsite"}, {Name: "usb"}}
+	err := multirepo.Run(context.Background(), repos, hc, &out, func(_ context.Context, r *entity.Repository) error {
+		visited = append(visited, r.Name)
+		if r.Name == "offsite" {
+			return errors.New("host unreachable")
+		}
+		return nil
+	})
+
+	require.ErrorContains(t, err, "offsite")
+	require.ErrorContains(t, err, "host unreachable")
+	assert.Equal(t, []string{"nas", "offsite", "usb"}, visited)
+
+	assert.True(t, hc.started)
+	assert.Nil(t, hc.success)
+	require.NotNil(t, hc.fail)
+	assert.Len(t, hc.fail.SuccessJobs, 2)
+	assert.Len(t, hc.fail.FailedJobs, 1)
+
+	assert.Regexp(t, `nas\s+ok`, out.String())
+	assert.Regexp(t, `offsite\s+failed`, out.String())
+	assert.Regexp(t, `usb\s+ok`, out.String())
+}
+
+func TestRunSingleRepository(t *testing.T) {
+	hc := &fakeHC{}
+	var out bytes.Buffer
+
+	repos := []*entity.Repository{{Name: "nas"}}
+	err := multirepo.Run(context.Background(), repos, hc, &out, func(context.Context, *entity.Repository) error {
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.NotNil(t, hc.success)
+	assert.Empty(t, out.String())
+}
@@ -2,34 +2,125 @@ package restore
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/logger"
 	"github.com/alexander-kolodka/crestic/internal/restic"
 )
 
+// inPlaceTarget restores files to their original absolute locations.
+const inPlaceTarget = "/"
+
 type Command struct {
-	Repo     *entity.Repository
-	Target   string
-	Snapshot string
+	Repo      *entity.Repository
+	Filter    entity.SnapshotFilter // Selects the snapshot when Snapshot is "latest"
+	Snapshot  string
+	Target    string
+	InPlace   bool
+	Include   []string
+	Exclude   []string
+	Overwrite string
+	DryRun    bool
+}
+
+// Confirmer asks the user to approve a destructive action.
+type Confirmer interface {
+	Confirm(prompt string) (bool, error)
 }
 
 type Handler struct {
-	restic *restic.Service
+	restic  *restic.Service
+	confirm Confirmer
 }
 
-func NewHandler(restic *restic.Service) *Handler {
+func NewHandler(restic *restic.Service, confirm Confirmer) *Handler {
 	return &Handler{
-		restic: restic,
+		restic:  restic,
+		confirm: confirm,
 	}
 }
 
 func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
 	ctx = logger.WithRepoFields(ctx, cmd.Repo)
+	if cmd.DryRun {
+		ctx = restic.WithDryRun(ctx)
+	}
+
+	snapshot, err := h.resolveSnapshot(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	target := cmd.Target
+	if cmd.InPlace {
+		target = inPlaceTarget
+	}
+
 	ctx = logger.FromContext(ctx).With().
-		Str("snapshot", cmd.Snapshot).
-		Str("target", cmd.Target).
+		Str("snapshot", snapshot.ShortID).
+		Time("snapshot_time", snapshot.Time).
+		Str("target", target).
+		Bool("dry_run", cmd.DryRun).
 		Logger().WithContext(ctx)
 
-	return h.restic.Restore(ctx, cmd.Repo, cmd.Target, cmd.Snapshot)
+	if cmd.InPlace && !cmd.DryRun {
+		err = h.confirmInPlace(cmd, snapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	return h.restic.Restore(ctx, cmd.Repo, snapshot.ID, restic.RestoreOptions{
+		Target:    target,
+		Include:   cmd.Include,
+		Exclude:   cmd.Exclude,
+		Overwrite: cmd.Overwrite,
+	})
+}
+
+// resolveSnapshot looks up the snapshot to restore, so the exact snapshot
+// is known before anything is written.
+func (h *Handler) resolveSnapshot(ctx context.Context, cmd *Command) (restic.Snapshot, error) {
+	snapshots, err := h.restic.Snapshots(ctx, cmd.Repo, cmd.Filter, cmd.Snapshot)
+	if err != nil {
+		return restic.Snapshot{}, err
+	}
+
+	if len(snapshots) == 0 {
+		return restic.Snapshot{}, fmt.Errorf("no matching snapshot %q found in repository %s", cmd.Snapshot, cmd.Repo.Name)
+	}
+
+	return snapshots[len(snapshots)-1], nil
+}
+
+func (h *Handler) confirmInPlace(cmd *Command, s restic.Snapshot) error {
+	prompt := fmt.Sprintf(
+		"Restore snapshot %s from %s (%s) in place? Files in %v may be overwritten (overwrite mode: %s).",
+		s.ShortID,
+		s.Time.Local().Format(time.DateTime),
+		cmd.Repo.Name,
+		s.Paths,
+		overwriteMode(cmd.Overwrite),
+	)
+
+	ok, err := h.confirm.Confirm(prompt)
+	if err != nil {
+		return fmt.Errorf("confirm restore: %w", err)
+	}
+
+	if !ok {
+		return errors.New("restore aborted")
+	}
+
+	return nil
+}
+
+func overwriteMode(mode string) string {
+	if mode == "" {
+		return "restic default"
+	}
+	return mode
 }
@@ -0,0 +1,111 @@
+package restore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/restore"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic has a single snapshot and records restic calls.
+type fakeRestic struct {
+	calls []string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	f.calls = append(f.calls, strings.Join(args, " "))
+
+	if args[0] == "snapshots" {
+		return &shell.Result{
+			Stdout: `[{"id":"abc123full","short_id":"abc123","time":"2025-03-01T12:00:00Z","paths":["/docs"]}]`,
+		}
+	}
+	return &shell.Result{}
+}
+
+// confirmer records the prompts it was asked and gives the same answer to all of them.
+type confirmer struct {
+	answer  bool
+	prompts []string
+}
+
+func (c *confirmer) Confirm(prompt string) (bool, error) {
+	c.prompts = append(c.prompts, prompt)
+	return c.answer, nil
+}
+
+func command() *restore.Command {
+	return &restore.Command{
+		Repo:     &entity.Repository{Name: "nas", Path: "/nas", PasswordCMD: "pass"},
+		Filter:   entity.SnapshotFilter{Paths: []string{"/docs"}},
+		Snapshot: "latest",
+		Include:  []string{"/docs/taxes"},
+	}
+}
+
+func TestRestoreInPlace(t *testing.T) {
+	fake := &fakeRestic{}
+	confirm := &confirmer{answer: true}
+	cmd := command()
+	cmd.InPlace = true
+	cmd.Overwrite = "if-newer"
+
+	err := restore.NewHandler(restic.NewService(fake), confirm).Handle(context.Background(), cmd)
+	require.NoError(t, err)
+
+	require.Len(t, confirm.prompts, 1)
+	assert.Contains(t, confirm.prompts[0], "Restore snapshot abc123")
+	assert.Contains(t, confirm.prompts[0], "Files in [/docs] may be overwritten (overwrite mode: if-newer)")
+
+	assert.Equal(t, []string{
+		"snapshots --json -r /nas --password-command pass --path /docs latest",
+		"restore --target / -r /nas --password-command pass --overwrite if-newer --include /docs/taxes abc123full",
+	}, fake.calls)
+}
+
+func TestRestoreInPlaceAborted(t *testing.T) {
+	fake := &fakeRestic{}
+	cmd := command()
+	cmd.InPlace = true
+
+	err := restore.NewHandler(restic.NewService(fake), &confirmer{}).Handle(context.Background(), cmd)
+	require.EqualError(t, err, "restore aborted")
+
+	assert.Len(t, fake.calls, 1, "nothing is restored")
+}
+
+func TestRestoreInPlaceDryRunDoesNotAsk(t *testing.T) {
+	fake := &fakeRestic{}
+	confirm := &confirmer{}
+	cmd := command()
+	cmd.InPlace = true
+	cmd.DryRun = true
+
+	err := restore.NewHandler(restic.NewService(fake), confirm).Handle(context.Background(), cmd)
+	require.NoError(t, err)
+
+	assert.Empty(t, confirm.prompts)
+	assert.Contains(t, fake.calls,
+		"restore --target / -r /nas --password-command pass --dry-run --include /docs/taxes abc123full")
+}
+
+func TestRestoreToTargetDoesNotAsk(t *testing.T) {
+	fake := &fakeRestic{}
+	confirm := &confirmer{}
+	cmd := command()
+	cmd.Target = "/tmp/restore"
+
+	err := restore.NewHandler(restic.NewService(fake), confirm).Handle(context.Background(), cmd)
+	require.NoError(t, err)
+
+	assert.Empty(t, confirm.prompts)
+	assert.Contains(t, fake.calls,
+		"restore --target /tmp/restore -r /nas --password-command pass --include /docs/taxes abc123full")
+}
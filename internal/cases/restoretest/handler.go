@@ -0,0 +1,99 @@
+package restoretest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/restoretest"
+)
+
+type Command struct {
+	Jobs   []entity.Job
+	Sample int // Overrides restore_test.sample of every job if set
+}
+
+type HealthChecks interface {
+	Start(ctx context.Context, rid string, j *healthchecks.JobsList) error
+	Success(ctx context.Context, rid string, r *entity.JobResults) error
+	Fail(ctx context.Context, rid string, r *entity.JobResults) error
+}
+
+type Handler struct {
+	tester *restoretest.Tester
+	hc     HealthChecks
+}
+
+func NewHandler(tester *restoretest.Tester, hc HealthChecks) *Handler {
+	return &Handler{
+		tester: tester,
+		hc:     hc,
+	}
+}
+
+// Handle runs the restore test of every backup job with restore_test configured.
+// With a Sample override, backup jobs without restore_test are tested against their source.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	tests := lo.FilterMap(cmd.Jobs, func(j entity.Job, _ int) (test, bool) {
+		t, ok := newTest(j, cmd.Sample)
+		if !ok {
+			log := logger.FromContext(ctx)
+			log.Debug().Str("job", j.GetName()).Msg("Skip job without restore_test")
+		}
+		return t, ok
+	})
+
+	if len(tests) == 0 {
+		return errors.New("no jobs with restore_test to run")
+	}
+
+	rid := uuid.NewString()
+	_ = h.hc.Start(ctx, rid, healthchecks.NewJobsList(lo.Map(tests, func(t test, _ int) string {
+		return t.job.Name
+	})))
+
+	jobResults := entity.NewJobResults()
+	for _, t := range tests {
+		start := time.Now()
+		_, err := h.tester.Run(ctx, t.job, t.config)
+		jobResults.Add(t.job.Name, time.Since(start), err)
+	}
+
+	if jobResults.HasErrors() {
+		_ = h.hc.Fail(ctx, rid, jobResults)
+		return errors.New(jobResults.ErrorMsg())
+	}
+
+	_ = h.hc.Success(ctx, rid, jobResults)
+	return nil
+}
+
+// test is a restore test of a single job.
+type test struct {
+	job    entity.BackupJob
+	config entity.RestoreTest
+}
+
+func newTest(j entity.Job, sample int) (test, bool) {
+	b, ok := j.(entity.BackupJob)
+	if !ok || (b.RestoreTest == nil && sample == 0) {
+		return test{}, false
+	}
+
+	t := test{job: b, config: entity.RestoreTest{Compare: entity.CompareSource}}
+	if b.RestoreTest != nil {
+		t.config = *b.RestoreTest
+	}
+
+	if sample > 0 {
+		t.config.Sample = sample
+	}
+
+	return t, true
+}
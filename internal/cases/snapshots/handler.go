@@ -0,0 +1,112 @@
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+type Command struct {
+	Queries []entity.SnapshotQuery
+	JSON    bool
+	Out     io.Writer
+}
+
+// Entry is a snapshot annotated with where it was found.
+type Entry struct {
+	restic.Snapshot
+
+	Repository string `json:"repository"`
+	Job        string `json:"job,omitempty"`
+}
+
+type Handler struct {
+	restic *restic.Service
+}
+
+func NewHandler(restic *restic.Service) *Handler {
+	return &Handler{
+		restic: restic,
+	}
+}
+
+// Handle lists snapshots of all queries concurrently and prints them merged by time.
+// A failing repository doesn't prevent listing the others; its error is returned at the end.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	results := make([][]Entry, len(cmd.Queries))
+	errs := make([]error, len(cmd.Queries))
+
+	var wg sync.WaitGroup
+	for i, q := range cmd.Queries {
+		wg.Go(func() {
+			results[i], errs[i] = h.list(ctx, q)
+		})
+	}
+	wg.Wait()
+
+	entries := slices.Concat(results...)
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	var err error
+	if cmd.JSON {
+		err = render.JSON(cmd.Out, entries)
+	} else {
+		err = printTable(cmd.Out, entries)
+	}
+
+	return errors.Join(append(errs, err)...)
+}
+
+func (h *Handler) list(ctx context.Context, q entity.SnapshotQuery) ([]Entry, error) {
+	ctx = logger.WithRepoFields(ctx, q.Repo)
+
+	snapshots, err := h.restic.Snapshots(ctx, q.Repo, q.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(snapshots))
+	for _, s := range snapshots {
+		entries = append(entries, Entry{
+			Snapshot:   s,
+			Repository: q.Repo.Name,
+			Job:        q.Job,
+		})
+	}
+
+	return entries, nil
+}
+
+func printTable(w io.Writer, entries []Entry) error {
+	t := render.NewTable(w, "TIME", "REPOSITORY", "JOB", "ID", "HOST", "TAGS", "SIZE", "PATHS")
+
+	for _, e := range entries {
+		size := "-"
+		if e.Summary != nil {
+			size = render.Bytes(e.Summary.TotalBytesProcessed)
+		}
+
+		t.Row(
+			render.Time(e.Time),
+			e.Repository,
+			render.OrDash(e.Job),
+			e.ShortID,
+			e.Hostname,
+			render.OrDash(strings.Join(e.Tags, ",")),
+			size,
+			strings.Join(e.Paths, ", "),
+		)
+	}
+
+	return t.Flush()
+}
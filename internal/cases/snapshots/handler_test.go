@@ -0,0 +1,125 @@
+package snapshots_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/snapshots"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// base is the time of the oldest snapshot returned by fakeRestic.
+var base = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeRestic returns two snapshots per repository, created hours[path] hours after base.
+// It fails for repositories with path "broken" and records the filter arguments.
+type fakeRestic struct {
+	mu      sync.Mutex
+	hours   map[string][2]int
+	filters map[string]string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	path := args[slices.Index(args, "-r")+1]
+
+	f.mu.Lock()
+	f.filters[path] = strings.Join(args[slices.Index(args, "pass")+1:], " ")
+	f.mu.Unlock()
+
+	if path == "broken" {
+		return &shell.Result{ExitCode: 12, Error: errors.New("wrong password")}
+	}
+
+	var list []string
+	for _, h := range f.hours[path] {
+		list = append(list, fmt.Sprintf(
+			`{"short_id":"%s-%d","time":%q,"hostname":"laptop","paths":["/docs"],"tags":["daily"],`+
+				`"summary":{"total_bytes_processed":2048}}`,
+			path, h, base.Add(time.Duration(h)*time.Hour).Format(time.RFC3339),
+		))
+	}
+	return &shell.Result{Stdout: "[" + strings.Join(list, ",") + "]"}
+}
+
+func newFake() *fakeRestic {
+	return &fakeRestic{
+		hours:   map[string][2]int{"a": {0, 3}, "b": {1, 2}},
+		filters: make(map[string]string),
+	}
+}
+
+func repo(path string) *entity.Repository {
+	return &entity.Repository{Name: path, Path: path, PasswordCMD: "pass"}
+}
+
+func TestSnapshotsMergedByTime(t *testing.T) {
+	fake := newFake()
+	var out bytes.Buffer
+
+	err := snapshots.NewHandler(restic.NewService(fake)).Handle(context.Background(), &snapshots.Command{
+		Queries: []entity.SnapshotQuery{
+			{Repo: repo("a"), Job: "docs", Filter: entity.SnapshotFilter{Paths: []string{"/docs"}, Tags: []string{"daily"}}},
+			{Repo: repo("b"), Filter: entity.SnapshotFilter{Host: "laptop"}},
+		},
+		JSON: true,
+		Out:  &out,
+	})
+	require.NoError(t, err)
+
+	var entries []snapshots.Entry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+
+	field := func(fn func(e snapshots.Entry) string) []string {
+		return lo.Map(entries, func(e snapshots.Entry, _ int) string { return fn(e) })
+	}
+	assert.Equal(t, []string{"a-0", "b-1", "b-2", "a-3"}, field(func(e snapshots.Entry) string { return e.ShortID }))
+	assert.Equal(t, []string{"a", "b", "b", "a"}, field(func(e snapshots.Entry) string { return e.Repository }))
+	assert.Equal(t, []string{"docs", "", "", "docs"}, field(func(e snapshots.Entry) string { return e.Job }))
+
+	assert.Equal(t, map[string]string{
+		"a": "--path /docs --tag daily",
+		"b": "--host laptop",
+	}, fake.filters)
+}
+
+func TestSnapshotsTable(t *testing.T) {
+	var out bytes.Buffer
+
+	err := snapshots.NewHandler(restic.NewService(newFake())).Handle(context.Background(), &snapshots.Command{
+		Queries: []entity.SnapshotQuery{{Repo: repo("a"), Job: "docs"}},
+		Out:     &out,
+	})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `TIME\s+REPOSITORY\s+JOB\s+ID\s+HOST\s+TAGS\s+SIZE\s+PATHS`, out.String())
+	assert.Regexp(t, `a\s+docs\s+a-0\s+laptop\s+daily\s+2.0 KiB\s+/docs`, out.String())
+}
+
+func TestSnapshotsContinueAfterFailedRepository(t *testing.T) {
+	var out bytes.Buffer
+
+	err := snapshots.NewHandler(restic.NewService(newFake())).Handle(context.Background(), &snapshots.Command{
+		Queries: []entity.SnapshotQuery{{Repo: repo("broken")}, {Repo: repo("b")}},
+		JSON:    true,
+		Out:     &out,
+	})
+	require.ErrorContains(t, err, "repository broken")
+
+	var entries []snapshots.Entry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	assert.Len(t, entries, 2, "snapshots of the other repository are listed")
+}
@@ -0,0 +1,183 @@
+package status
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+type Command struct {
+	Repos []*entity.Repository
+	Jobs  []entity.Job
+	JSON  bool
+	Color bool
+	Out   io.Writer
+}
+
+type Handler struct {
+	restic *restic.Service
+}
+
+func NewHandler(restic *restic.Service) *Handler {
+	return &Handler{
+		restic: restic,
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	ctx = restic.WithQuietErrors(shell.WithSilence(ctx))
+
+	statuses := make([]*RepoStatus, len(cmd.Repos))
+
+	var wg sync.WaitGroup
+	for i, repo := range cmd.Repos {
+		wg.Go(func() {
+			repoCtx := logger.WithRepoFields(ctx, repo)
+			statuses[i] = h.repoStatus(repoCtx, repo, queriesForRepo(cmd.Jobs, repo))
+		})
+	}
+	wg.Wait()
+
+	slices.SortFunc(statuses, func(a, b *RepoStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if cmd.JSON {
+		return printJSON(cmd.Out, statuses)
+	}
+
+	return printTable(cmd.Out, statuses, cmd.Color)
+}
+
+func (h *Handler) repoStatus(ctx context.Context, repo *entity.Repository, queries []entity.SnapshotQuery) *RepoStatus {
+	now := time.Now()
+	s := &RepoStatus{
+		Name: repo.Name,
+		Path: repo.Path,
+		Jobs: []JobStatus{},
+		Tags: []TagStatus{},
+	}
+
+	initialized, err := h.restic.IsRepoInitialized(ctx, repo)
+	if err != nil {
+		s.State = StateUnreachable
+		s.addError(err)
+		return s
+	}
+
+	if !initialized {
+		s.State = StateNotInitialized
+		return s
+	}
+
+	s.State = StateOK
+
+	snapshots, err := h.restic.Snapshots(ctx, repo, entity.SnapshotFilter{})
+	if err != nil {
+		s.addError(err)
+	} else {
+		s.SnapshotCount = len(snapshots)
+		s.LatestSnapshot = latest(snapshots)
+		s.Jobs = lo.Map(queries, func(q entity.SnapshotQuery, _ int) JobStatus {
+			return jobStatus(q, snapshots, now)
+		})
+		s.Tags = tagStatuses(snapshots)
+	}
+
+	stats, err := h.restic.Stats(ctx, repo)
+	if err != nil {
+		s.addError(err)
+	} else {
+		s.TotalSize = stats.TotalSize
+	}
+
+	locks, err := h.restic.Locks(ctx, repo)
+	if err != nil {
+		s.addError(err)
+	} else {
+		s.Locks = len(locks)
+		s.StaleLocks = lo.CountBy(locks, func(l restic.Lock) bool {
+			return l.IsStale(now)
+		})
+	}
+
+	return s
+}
+
+// queriesForRepo returns the snapshot queries of jobs writing to the repository.
+// Jobs with sources have a query per database.
+func queriesForRepo(jobs []entity.Job, repo *entity.Repository) []entity.SnapshotQuery {
+	var queries []entity.SnapshotQuery
+	for _, j := range jobs {
+		jobQueries, _ := entity.SnapshotQueries(j)
+		queries = append(queries, lo.Filter(jobQueries, func(q entity.SnapshotQuery, _ int) bool {
+			return q.Repo.Name == repo.Name
+		})...)
+	}
+	return queries
+}
+
+func jobStatus(q entity.SnapshotQuery, snapshots []restic.Snapshot, now time.Time) JobStatus {
+	matching := lo.Filter(snapshots, func(s restic.Snapshot, _ int) bool {
+		return s.Matches(q.Filter)
+	})
+
+	js := JobStatus{
+		Name:           q.Job,
+		SnapshotCount:  len(matching),
+		LatestSnapshot: latest(matching),
+	}
+	if js.LatestSnapshot != nil {
+		js.Age = now.Sub(js.LatestSnapshot.Time).Round(time.Second).String()
+	}
+
+	return js
+}
+
+func tagStatuses(snapshots []restic.Snapshot) []TagStatus {
+	byTag := make(map[string][]restic.Snapshot)
+	for _, s := range snapshots {
+		for _, t := range s.Tags {
+			byTag[t] = append(byTag[t], s)
+		}
+	}
+
+	tags := lo.MapToSlice(byTag, func(tag string, snapshots []restic.Snapshot) TagStatus {
+		return TagStatus{
+			Tag:            tag,
+			SnapshotCount:  len(snapshots),
+			LatestSnapshot: latest(snapshots),
+		}
+	})
+
+	slices.SortFunc(tags, func(a, b TagStatus) int {
+		return strings.Compare(a.Tag, b.Tag)
+	})
+
+	return tags
+}
+
+func latest(snapshots []restic.Snapshot) *SnapshotInfo {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	s := lo.MaxBy(snapshots, func(a, b restic.Snapshot) bool {
+		return a.Time.After(b.Time)
+	})
+
+	return &SnapshotInfo{
+		ID:   s.ShortID,
+		Time: s.Time,
+	}
+}
@@ -0,0 +1,135 @@
+package status_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/status"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic serves repositories by path: "missing" is not initialized, "offline" can't be reached,
+// "locked" has a fresh and a stale lock, and every other repository holds one snapshot of /docs.
+type fakeRestic struct {
+	now time.Time
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	path := args[slices.Index(args, "-r")+1]
+	cmd := strings.Join(args[:2], " ")
+
+	switch {
+	case path == "offline":
+		return &shell.Result{ExitCode: 1, Error: errors.New("dial tcp: connection refused")}
+	case path == "missing" && args[0] == "stats":
+		return &shell.Result{ExitCode: 10, Error: errors.New("repository does not exist")}
+	case args[0] == "snapshots":
+		return &shell.Result{Stdout: fmt.Sprintf(
+			`[{"short_id":"abc123","time":%q,"paths":["/docs"],"tags":["daily"]}]`,
+			f.now.Add(-2*time.Hour).Format(time.RFC3339),
+		)}
+	case cmd == "stats --json":
+		return &shell.Result{Stdout: `{"total_size":2048}`}
+	case cmd == "list locks" && path == "locked":
+		return &shell.Result{Stdout: "fresh\nstale\n"}
+	case cmd == "cat lock":
+		age := time.Minute
+		if args[2] == "stale" {
+			age = time.Hour
+		}
+		return &shell.Result{Stdout: fmt.Sprintf(`{"time":%q}`, f.now.Add(-age).Format(time.RFC3339))}
+	}
+	return &shell.Result{}
+}
+
+func repo(path string) *entity.Repository {
+	return &entity.Repository{Name: path, Path: path, PasswordCMD: "pass"}
+}
+
+func handle(t *testing.T, ctx context.Context, asJSON bool, repos ...*entity.Repository) string {
+	t.Helper()
+	var out bytes.Buffer
+
+	err := status.NewHandler(restic.NewService(&fakeRestic{now: time.Now()})).Handle(ctx, &status.Command{
+		Repos: repos,
+		Jobs: []entity.Job{
+			entity.BackupJob{Name: "docs", From: []string{"/docs"}, To: repos[0]},
+			entity.BackupJob{Name: "photos", From: []string{"/photos"}, To: repos[0]},
+		},
+		JSON: asJSON,
+		Out:  &out,
+	})
+	require.NoError(t, err)
+
+	return out.String()
+}
+
+func TestStatusStates(t *testing.T) {
+	out := handle(t, context.Background(), true, repo("ok"), repo("missing"), repo("offline"), repo("locked"))
+
+	var statuses []status.RepoStatus
+	require.NoError(t, json.Unmarshal([]byte(out), &statuses))
+	require.Len(t, statuses, 4)
+
+	byName := make(map[string]status.RepoStatus, len(statuses))
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+
+	ok := byName["ok"]
+	assert.Equal(t, status.StateOK, ok.State)
+	assert.Equal(t, 1, ok.SnapshotCount)
+	assert.Equal(t, uint64(2048), ok.TotalSize)
+	assert.Zero(t, ok.Locks)
+	assert.Empty(t, ok.Error)
+	require.Len(t, ok.Jobs, 2)
+	assert.Equal(t, "docs", ok.Jobs[0].Name)
+	assert.Equal(t, 1, ok.Jobs[0].SnapshotCount)
+	assert.Regexp(t, `^2h0m[01]s$`, ok.Jobs[0].Age)
+	assert.Equal(t, "photos", ok.Jobs[1].Name)
+	assert.Nil(t, ok.Jobs[1].LatestSnapshot)
+	assert.Equal(t, []status.TagStatus{{Tag: "daily", SnapshotCount: 1, LatestSnapshot: ok.LatestSnapshot}}, ok.Tags)
+
+	assert.Equal(t, status.StateNotInitialized, byName["missing"].State)
+	assert.Empty(t, byName["missing"].Error)
+
+	assert.Equal(t, status.StateUnreachable, byName["offline"].State)
+	assert.Contains(t, byName["offline"].Error, "connection refused")
+
+	assert.Equal(t, status.StateOK, byName["locked"].State)
+	assert.Equal(t, 2, byName["locked"].Locks)
+	assert.Equal(t, 1, byName["locked"].StaleLocks)
+}
+
+func TestStatusTable(t *testing.T) {
+	out := handle(t, context.Background(), false, repo("ok"), repo("offline"), repo("locked"))
+
+	assert.Regexp(t, `locked\s+ok\s+1\s+2.0 KiB\s+\S+ \S+\s+2 \(1 stale\)`, out)
+	assert.Regexp(t, `offline\s+unreachable\s+0\s+-\s+-\s+-`, out)
+	assert.Regexp(t, `ok\s+docs\s+1\s+\S+ \S+\s+2h0m[01]s`, out)
+	assert.Regexp(t, `ok\s+photos\s+0\s+-\s+-`, out)
+	assert.Contains(t, out, "offline: repository offline: restic stats failed")
+	assert.NotContains(t, out, "\x1b[", "no colors unless requested")
+}
+
+func TestStatusLogsUnreachableReposQuietly(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := zerolog.New(&logs).Level(zerolog.InfoLevel).WithContext(context.Background())
+
+	handle(t, ctx, true, repo("offline"))
+
+	assert.Empty(t, logs.String(), "failures are reported in the output only")
+}
@@ -0,0 +1,57 @@
+package status
+
+import (
+	"strings"
+	"time"
+)
+
+// State describes whether a repository can be used.
+type State string
+
+const (
+	StateOK             State = "ok"
+	StateNotInitialized State = "not initialized"
+	StateUnreachable    State = "unreachable"
+)
+
+// RepoStatus is the overview of a single repository.
+type RepoStatus struct {
+	Name           string        `json:"name"`
+	Path           string        `json:"path"`
+	State          State         `json:"state"`
+	SnapshotCount  int           `json:"snapshot_count"`
+	LatestSnapshot *SnapshotInfo `json:"latest_snapshot,omitempty"`
+	TotalSize      uint64        `json:"total_size"`
+	Locks          int           `json:"locks"`
+	StaleLocks     int           `json:"stale_locks"`
+	Jobs           []JobStatus   `json:"jobs"`
+	Tags           []TagStatus   `json:"tags"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// JobStatus summarizes snapshots created by a job in the repository.
+type JobStatus struct {
+	Name           string        `json:"name"`
+	SnapshotCount  int           `json:"snapshot_count"`
+	LatestSnapshot *SnapshotInfo `json:"latest_snapshot,omitempty"`
+	Age            string        `json:"age,omitempty"`
+}
+
+// TagStatus summarizes snapshots carrying a tag.
+type TagStatus struct {
+	Tag            string        `json:"tag"`
+	SnapshotCount  int           `json:"snapshot_count"`
+	LatestSnapshot *SnapshotInfo `json:"latest_snapshot,omitempty"`
+}
+
+type SnapshotInfo struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+func (s *RepoStatus) addError(err error) {
+	if s.Error != "" {
+		s.Error += "; "
+	}
+	s.Error += strings.TrimSpace(err.Error())
+}
@@ -0,0 +1,119 @@
+package status
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/alexander-kolodka/crestic/internal/render"
+)
+
+func printJSON(w io.Writer, statuses []*RepoStatus) error {
+	return render.JSON(w, statuses)
+}
+
+func printTable(w io.Writer, statuses []*RepoStatus, color bool) error {
+	p := render.NewPainter(color)
+
+	repos := render.NewTable(w, "REPOSITORY", "STATE", "SNAPSHOTS", "SIZE", "LATEST", "LOCKS")
+	for _, s := range statuses {
+		repos.Row(
+			s.Name,
+			p.Paint(stateColor(s.State), string(s.State)),
+			strconv.Itoa(s.SnapshotCount),
+			size(s),
+			snapshotTime(s.LatestSnapshot),
+			p.Paint(locksColor(s), locks(s)),
+		)
+	}
+
+	err := repos.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(w)
+
+	jobs := render.NewTable(w, "REPOSITORY", "JOB", "SNAPSHOTS", "LATEST", "AGE")
+	for _, s := range statuses {
+		for _, j := range s.Jobs {
+			jobs.Row(
+				s.Name,
+				j.Name,
+				strconv.Itoa(j.SnapshotCount),
+				p.Paint(jobColor(s, j), snapshotTime(j.LatestSnapshot)),
+				render.OrDash(j.Age),
+			)
+		}
+	}
+
+	err = jobs.Flush()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if s.Error != "" {
+			_, _ = fmt.Fprintf(w, "\n%s: %s\n", s.Name, p.Paint(render.Red, s.Error))
+		}
+	}
+
+	return nil
+}
+
+func snapshotTime(s *SnapshotInfo) string {
+	if s == nil {
+		return "-"
+	}
+	return render.Time(s.Time)
+}
+
+func size(s *RepoStatus) string {
+	if s.State != StateOK {
+		return "-"
+	}
+	return render.Bytes(s.TotalSize)
+}
+
+func locks(s *RepoStatus) string {
+	if s.State != StateOK {
+		return "-"
+	}
+
+	if s.StaleLocks > 0 {
+		return fmt.Sprintf("%d (%d stale)", s.Locks, s.StaleLocks)
+	}
+
+	return strconv.Itoa(s.Locks)
+}
+
+func stateColor(s State) render.Color {
+	switch s {
+	case StateOK:
+		return render.Green
+	case StateNotInitialized:
+		return render.Yellow
+	case StateUnreachable:
+		return render.Red
+	default:
+		return render.Default
+	}
+}
+
+func locksColor(s *RepoStatus) render.Color {
+	switch {
+	case s.StaleLocks > 0:
+		return render.Red
+	case s.Locks > 0:
+		return render.Yellow
+	default:
+		return render.Default
+	}
+}
+
+func jobColor(s *RepoStatus, j JobStatus) render.Color {
+	if s.State == StateOK && j.LatestSnapshot == nil {
+		return render.Yellow
+	}
+	return render.Default
+}
@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/render"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+// Chunker parameter states of a copy pair.
+const (
+	chunkerMatch    = "match"
+	chunkerMismatch = "MISMATCH"
+	chunkerPending  = "target not initialized"
+	chunkerSkipped  = "source not initialized"
+	chunkerUnknown  = "unknown"
+)
+
+type Command struct {
+	Jobs    []entity.Job
+	Offline bool // Only validate the config file, without accessing repositories
+	Out     io.Writer
+}
+
+type Handler struct {
+	restic *restic.Service
+}
+
+func NewHandler(restic *restic.Service) *Handler {
+	return &Handler{restic: restic}
+}
+
+// Handle reports on a config that has already been loaded, and so is valid,
+// and checks that the repositories of copy jobs share their chunker parameters.
+// Mismatches are warnings: copying works, but copied data isn't deduplicated
+// against data already in the target.
+func (h *Handler) Handle(ctx context.Context, cmd *Command) error {
+	_, _ = fmt.Fprintln(cmd.Out, "Configuration is valid.")
+
+	if cmd.Offline {
+		return nil
+	}
+
+	pairs := copyPairs(cmd.Jobs)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(cmd.Out)
+	t := render.NewTable(cmd.Out, "JOB", "FROM", "TO", "CHUNKER PARAMS")
+	for _, c := range pairs {
+		t.Row(c.Name, c.From.Name, c.To.Name, h.chunkerParams(ctx, c))
+	}
+
+	return t.Flush()
+}
+
+func (h *Handler) chunkerParams(ctx context.Context, c entity.CopyJob) string {
+	ctx = logger.FromContext(ctx).With().Str("job", c.Name).Logger().WithContext(ctx)
+	log := logger.FromContext(ctx)
+
+	fromInitialized, err := h.restic.IsRepoInitialized(logger.WithRepoFields(ctx, c.From), c.From)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to access copy source")
+		return chunkerUnknown
+	}
+	if !fromInitialized {
+		return chunkerSkipped
+	}
+
+	toInitialized, err := h.restic.IsRepoInitialized(logger.WithRepoFields(ctx, c.To), c.To)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to access copy target")
+		return chunkerUnknown
+	}
+	if !toInitialized {
+		// The target will be created with the chunker parameters of the source.
+		return chunkerPending
+	}
+
+	from, err := h.restic.Config(logger.WithRepoFields(ctx, c.From), c.From)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read copy source config")
+		return chunkerUnknown
+	}
+
+	to, err := h.restic.Config(logger.WithRepoFields(ctx, c.To), c.To)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read copy target config")
+		return chunkerUnknown
+	}
+
+	if from.ChunkerPolynomial != to.ChunkerPolynomial {
+		log.Warn().
+			Str("from", c.From.Name).
+			Str("to", c.To.Name).
+			Msg("Copy repositories have different chunker parameters, copied snapshots are not deduplicated")
+		return chunkerMismatch
+	}
+
+	return chunkerMatch
+}
+
+// copyPairs returns the copy jobs, one per distinct source and target pair.
+func copyPairs(jobs []entity.Job) []entity.CopyJob {
+	seen := make(map[[2]string]bool)
+	var pairs []entity.CopyJob
+	for _, j := range jobs {
+		c, ok := j.(entity.CopyJob)
+		if !ok {
+			continue
+		}
+
+		key := [2]string{c.From.Name, c.To.Name}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pairs = append(pairs, c)
+	}
+
+	return pairs
+}
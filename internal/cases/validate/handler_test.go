@@ -0,0 +1,81 @@
+package validate_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/cases/validate"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic serves repository configs with the given chunker polynomials.
+// Repositories without a polynomial are not initialized.
+type fakeRestic struct {
+	polynomials map[string]string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	path := args[slices.Index(args, "-r")+1]
+	polynomial, ok := f.polynomials[path]
+	if !ok {
+		return &shell.Result{ExitCode: 10, Error: errors.New("repository does not exist")}
+	}
+
+	if args[0] == "cat" {
+		return &shell.Result{Stdout: fmt.Sprintf(`{"version":2,"id":"%s","chunker_polynomial":"%s"}`, path, polynomial)}
+	}
+	return &shell.Result{}
+}
+
+func copyJob(name, from, to string) entity.CopyJob {
+	return entity.CopyJob{
+		Name: name,
+		From: &entity.Repository{Name: from, Path: from},
+		To:   &entity.Repository{Name: to, Path: to},
+	}
+}
+
+func TestValidateChunkerParams(t *testing.T) {
+	fake := &fakeRestic{polynomials: map[string]string{"nas": "3dea92648f6e83", "usb": "3dea92648f6e83", "s3": "2f1b7e0d4c2a15"}}
+	var out bytes.Buffer
+
+	err := validate.NewHandler(restic.NewService(fake)).Handle(context.Background(), &validate.Command{
+		Jobs: []entity.Job{
+			copyJob("to-usb", "nas", "usb"),
+			copyJob("to-s3", "nas", "s3"),
+			copyJob("to-s3-again", "nas", "s3"),
+			copyJob("to-new", "nas", "new"),
+		},
+		Out: &out,
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, out.String(), "Configuration is valid.")
+	assert.Regexp(t, `to-usb\s+nas\s+usb\s+match`, out.String())
+	assert.Regexp(t, `to-s3\s+nas\s+s3\s+MISMATCH`, out.String())
+	assert.NotContains(t, out.String(), "to-s3-again", "pairs are checked once")
+	assert.Regexp(t, `to-new\s+nas\s+new\s+target not initialized`, out.String())
+}
+
+func TestValidateOffline(t *testing.T) {
+	fake := &fakeRestic{}
+	var out bytes.Buffer
+
+	err := validate.NewHandler(restic.NewService(fake)).Handle(context.Background(), &validate.Command{
+		Jobs:    []entity.Job{copyJob("to-usb", "nas", "usb")},
+		Offline: true,
+		Out:     &out,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Configuration is valid.\n", out.String())
+}
@@ -0,0 +1,136 @@
+// Package dbdump builds the shell commands that dump databases to stdout,
+// so their output can be backed up with restic --stdin-from-command.
+package dbdump
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// Postgres returns the dump of a PostgreSQL database in pg_dump custom format.
+// The database replaces the one given in dsn, which may be a URI or a key=value connection string.
+func Postgres(dsn, database string) (entity.Dump, error) {
+	err := validateDatabase(database)
+	if err != nil {
+		return entity.Dump{}, err
+	}
+
+	conn, err := postgresDSN(dsn, database)
+	if err != nil {
+		return entity.Dump{}, err
+	}
+
+	return entity.Dump{
+		Kind:     entity.DumpPostgres,
+		Database: database,
+		Source: entity.CommandSource{
+			Command:  "pg_dump --format=custom --dbname=" + shell.Quote(conn),
+			Filename: database + ".dump",
+		},
+	}, nil
+}
+
+// MySQLOptions are the connection options passed to mysqldump.
+// Empty fields are left to the mysqldump defaults.
+type MySQLOptions struct {
+	Host         string
+	Port         int
+	User         string
+	DefaultsFile string // Option file with credentials, passed as --defaults-file
+}
+
+// MySQL returns the SQL dump of a MySQL or MariaDB database.
+// InnoDB tables are dumped in a single transaction without locking them.
+func MySQL(opts MySQLOptions, database string) (entity.Dump, error) {
+	err := validateDatabase(database)
+	if err != nil {
+		return entity.Dump{}, err
+	}
+
+	args := []string{"mysqldump"}
+	// --defaults-file is only recognized as the first option.
+	if opts.DefaultsFile != "" {
+		args = append(args, "--defaults-file="+shell.Quote(opts.DefaultsFile))
+	}
+	if opts.Host != "" {
+		args = append(args, "--host="+shell.Quote(opts.Host))
+	}
+	if opts.Port != 0 {
+		args = append(args, fmt.Sprintf("--port=%d", opts.Port))
+	}
+	if opts.User != "" {
+		args = append(args, "--user="+shell.Quote(opts.User))
+	}
+	args = append(args, "--single-transaction", "--routines", "--triggers", "--databases", shell.Quote(database))
+
+	return entity.Dump{
+		Kind:     entity.DumpMySQL,
+		Database: database,
+		Source: entity.CommandSource{
+			Command:  strings.Join(args, " "),
+			Filename: database + ".sql",
+		},
+	}, nil
+}
+
+// SQLite returns a consistent copy of an SQLite database file.
+// The copy is made with the sqlite3 .backup command, which uses the online backup API,
+// so the database may be in use while it's dumped.
+func SQLite(path string) (entity.Dump, error) {
+	if path == "" {
+		return entity.Dump{}, errors.New("sqlite path is required")
+	}
+
+	name := filepath.Base(path)
+
+	// sqlite3 silently creates missing databases, so a wrong path would produce empty backups.
+	// .backup needs a seekable file, so the copy is written to a temporary file first.
+	command := `test -f ` + shell.Quote(path) + ` || { echo "database not found: "` + shell.Quote(path) + ` >&2; exit 1; }; ` +
+		`tmp=$(mktemp) && trap 'rm -f "$tmp"' EXIT && ` +
+		`sqlite3 ` + shell.Quote(path) + ` ".backup '$tmp'" && cat "$tmp"`
+
+	return entity.Dump{
+		Kind:     entity.DumpSQLite,
+		Database: name,
+		Source: entity.CommandSource{
+			Command:  command,
+			Filename: name,
+		},
+	}, nil
+}
+
+func validateDatabase(database string) error {
+	if database == "" {
+		return errors.New("database name is required")
+	}
+
+	if strings.Contains(database, "/") {
+		return fmt.Errorf("database name %q must not contain '/'", database)
+	}
+
+	return nil
+}
+
+// postgresDSN returns dsn pointing to the given database.
+func postgresDSN(dsn, database string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("parse postgres dsn: %w", err)
+		}
+
+		u.Path = "/" + database
+		u.RawPath = ""
+		return u.String(), nil
+	}
+
+	// In key=value connection strings the last occurrence of a keyword wins.
+	value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(database)
+	return strings.TrimSpace(dsn + " dbname='" + value + "'"), nil
+}
@@ -0,0 +1,116 @@
+package dbdump_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/dbdump"
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+func TestPostgres(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     string
+		command string
+	}{
+		{
+			name:    "uri",
+			dsn:     "postgres://backup@db.local:5432/postgres?sslmode=disable",
+			command: "pg_dump --format=custom --dbname='postgres://backup@db.local:5432/app?sslmode=disable'",
+		},
+		{
+			name:    "key value",
+			dsn:     "host=db.local user=backup dbname=postgres",
+			command: `pg_dump --format=custom --dbname='host=db.local user=backup dbname=postgres dbname='\''app'\'''`,
+		},
+		{
+			name:    "empty",
+			command: `pg_dump --format=custom --dbname='dbname='\''app'\'''`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := dbdump.Postgres(tt.dsn, "app")
+			require.NoError(t, err)
+
+			assert.Equal(t, entity.DumpPostgres, d.Kind)
+			assert.Equal(t, "app", d.Database)
+			assert.Equal(t, "app.dump", d.Source.Filename)
+			assert.Equal(t, tt.command, d.Source.Command)
+		})
+	}
+}
+
+func TestMySQL(t *testing.T) {
+	d, err := dbdump.MySQL(dbdump.MySQLOptions{
+		Host:         "db.local",
+		Port:         3306,
+		User:         "backup",
+		DefaultsFile: "/etc/crestic/my.cnf",
+	}, "app")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"mysqldump --defaults-file='/etc/crestic/my.cnf' --host='db.local' --port=3306 --user='backup' "+
+			"--single-transaction --routines --triggers --databases 'app'",
+		d.Source.Command,
+	)
+	assert.Equal(t, "app.sql", d.Source.Filename)
+	assert.Equal(t, []string{"mysql", "db:app"}, d.Tags())
+}
+
+func TestInvalidDatabase(t *testing.T) {
+	_, err := dbdump.Postgres("", "")
+	require.Error(t, err)
+
+	_, err = dbdump.MySQL(dbdump.MySQLOptions{}, "a/b")
+	require.Error(t, err)
+
+	_, err = dbdump.SQLite("")
+	require.Error(t, err)
+}
+
+func TestSQLite(t *testing.T) {
+	_, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app's.db")
+	out, err := exec.Command("sqlite3", src,
+		"CREATE TABLE notes (body TEXT); INSERT INTO notes VALUES ('hello');",
+	).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	d, err := dbdump.SQLite(src)
+	require.NoError(t, err)
+	assert.Equal(t, "app's.db", d.Source.Filename)
+
+	dump, err := exec.Command("sh", "-c", d.Source.Command).Output()
+	require.NoError(t, err)
+
+	restored := filepath.Join(dir, "restored.db")
+	require.NoError(t, os.WriteFile(restored, dump, 0o600))
+
+	out, err = exec.Command("sqlite3", restored, "SELECT body FROM notes").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "hello\n", string(out))
+}
+
+func TestSQLiteMissingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	d, err := dbdump.SQLite(path)
+	require.NoError(t, err)
+
+	err = exec.Command("sh", "-c", d.Source.Command).Run()
+	require.Error(t, err)
+	assert.NoFileExists(t, path)
+}
@@ -1,22 +1,87 @@
 package dto
 
+import "time"
+
 // Config is the YAML configuration structure.
 type Config struct {
 	Repositories   map[string]Repository `yaml:"repositories"`
 	Jobs           Jobs                  `yaml:"jobs"`
 	HealthcheckURL string                `yaml:"healthcheck_url"`
+	Healthcheck    Healthcheck           `yaml:"healthcheck"`
+	Redact         []string              `yaml:"redact"`
+}
+
+type Healthcheck struct {
+	PingURL string        `yaml:"ping_url"`
+	PingKey string        `yaml:"ping_key"`
+	Slug    string        `yaml:"slug"`
+	Timeout time.Duration `yaml:"timeout"`
+	Retries int           `yaml:"retries"`
+	Backoff time.Duration `yaml:"backoff"`
 }
 
 type Options map[string]any
 
 type BackupJob struct {
-	Name                     string   `yaml:"name"`
-	Cron                     string   `yaml:"cron"`
-	IgnoreMissingXAttrsError bool     `yaml:"ignore_x_attrs_error"`
-	From                     []string `yaml:"from"`
-	To                       string   `yaml:"to"`
-	Options                  Options  `yaml:"options"`
-	Hooks                    Hooks    `yaml:"hooks"`
+	Name                     string         `yaml:"name"`
+	Cron                     string         `yaml:"cron"`
+	IgnoreMissingXAttrsError bool           `yaml:"ignore_x_attrs_error"`
+	From                     []string       `yaml:"from"`
+	FromCommand              *CommandSource `yaml:"from_command"`
+	Sources                  []Source       `yaml:"sources"`
+	Snapshot                 *Snapshot      `yaml:"snapshot"`
+	To                       string         `yaml:"to"`
+	Options                  Options        `yaml:"options"`
+	Hooks                    Hooks          `yaml:"hooks"`
+	Timeout                  string         `yaml:"timeout"`
+	MaxAge                   string         `yaml:"max_age"`
+	RestoreTest              *RestoreTest   `yaml:"restore_test"`
+}
+
+type CommandSource struct {
+	Command  string `yaml:"command"`
+	Filename string `yaml:"filename"`
+}
+
+// Source is a database backed up by a backup job. Exactly one field must be set.
+type Source struct {
+	Postgres *PostgresSource `yaml:"postgres"`
+	MySQL    *MySQLSource    `yaml:"mysql"`
+	SQLite   *SQLiteSource   `yaml:"sqlite"`
+}
+
+type PostgresSource struct {
+	DSN       string   `yaml:"dsn"`
+	Databases []string `yaml:"databases"`
+}
+
+type MySQLSource struct {
+	Host         string   `yaml:"host"`
+	Port         int      `yaml:"port"`
+	User         string   `yaml:"user"`
+	DefaultsFile string   `yaml:"defaults_file"`
+	Databases    []string `yaml:"databases"`
+}
+
+type SQLiteSource struct {
+	Path string `yaml:"path"`
+}
+
+type Snapshot struct {
+	Type       string `yaml:"type"`
+	Subvolume  string `yaml:"subvolume"`
+	Dataset    string `yaml:"dataset"`
+	Volume     string `yaml:"volume"`
+	Mountpoint string `yaml:"mountpoint"`
+	Size       string `yaml:"size"`
+}
+
+type RestoreTest struct {
+	Sample      int      `yaml:"sample"`
+	Paths       []string `yaml:"paths"`
+	Compare     string   `yaml:"compare"`
+	Manifest    string   `yaml:"manifest"`
+	AfterBackup bool     `yaml:"after_backup"`
 }
 
 type CopyJob struct {
@@ -26,16 +91,69 @@ type CopyJob struct {
 	To      string  `yaml:"to"`
 	Options Options `yaml:"options"`
 	Hooks   Hooks   `yaml:"hooks"`
+	Timeout string  `yaml:"timeout"`
+	MaxAge  string  `yaml:"max_age"`
+}
+
+type CheckJob struct {
+	Name           string   `yaml:"name"`
+	Cron           string   `yaml:"cron"`
+	Repositories   []string `yaml:"repositories"`
+	ReadDataSubset string   `yaml:"read_data_subset"`
+	Hooks          Hooks    `yaml:"hooks"`
+	Timeout        string   `yaml:"timeout"`
+}
+
+type PruneJob struct {
+	Name          string   `yaml:"name"`
+	Cron          string   `yaml:"cron"`
+	Repositories  []string `yaml:"repositories"`
+	MaxUnused     string   `yaml:"max_unused"`
+	MaxRepackSize string   `yaml:"max_repack_size"`
+	Hooks         Hooks    `yaml:"hooks"`
+	Timeout       string   `yaml:"timeout"`
+}
+
+type ForgetJob struct {
+	Name         string   `yaml:"name"`
+	Cron         string   `yaml:"cron"`
+	Repositories []string `yaml:"repositories"`
+	Options      Options  `yaml:"options"`
+	Hooks        Hooks    `yaml:"hooks"`
+	Timeout      string   `yaml:"timeout"`
+}
+
+type CommandJob struct {
+	Name    string            `yaml:"name"`
+	Cron    string            `yaml:"cron"`
+	Run     []string          `yaml:"run"`
+	Dir     string            `yaml:"dir"`
+	Env     map[string]string `yaml:"env"`
+	Timeout string            `yaml:"timeout"`
+	Hooks   Hooks             `yaml:"hooks"`
 }
 
 type Repository struct {
-	Path          string  `yaml:"path"`
-	PasswordCMD   string  `yaml:"password_command"`
-	ForgetOptions Options `yaml:"forget_options"`
+	Path          string            `yaml:"path"`
+	PasswordCMD   string            `yaml:"password_command"`
+	PasswordFile  string            `yaml:"password_file"`
+	PasswordEnv   string            `yaml:"password_env"`
+	CachePassword bool              `yaml:"cache_password"`
+	Password      string            `yaml:"password"`
+	Env           map[string]string `yaml:"env"`
+	ForgetOptions Options           `yaml:"forget_options"`
+	AutoInit      *bool             `yaml:"auto_init"`
+	InitOptions   InitOptions       `yaml:"init_options"`
+}
+
+type InitOptions struct {
+	RepositoryVersion     string `yaml:"repository_version"`
+	CopyChunkerParamsFrom string `yaml:"copy_chunker_params_from"`
 }
 
 type Hooks struct {
 	Before  []string `yaml:"before"`
 	Failure []string `yaml:"failure"`
 	Success []string `yaml:"success"`
+	Timeout string   `yaml:"timeout"`
 }
@@ -58,6 +58,34 @@ func (w *jobWrapper) UnmarshalYAML(value *yaml.Node) error {
 			return fmt.Errorf("copy: %w", dErr)
 		}
 		w.Job = c
+	case "check":
+		var c CheckJob
+		dErr := value.Decode(&c)
+		if dErr != nil {
+			return fmt.Errorf("check: %w", dErr)
+		}
+		w.Job = c
+	case "prune":
+		var p PruneJob
+		dErr := value.Decode(&p)
+		if dErr != nil {
+			return fmt.Errorf("prune: %w", dErr)
+		}
+		w.Job = p
+	case "forget":
+		var f ForgetJob
+		dErr := value.Decode(&f)
+		if dErr != nil {
+			return fmt.Errorf("forget: %w", dErr)
+		}
+		w.Job = f
+	case "command":
+		var c CommandJob
+		dErr := value.Decode(&c)
+		if dErr != nil {
+			return fmt.Errorf("command: %w", dErr)
+		}
+		w.Job = c
 	default:
 		return fmt.Errorf("unknown job type: %q", t.Type)
 	}
@@ -1,22 +1,46 @@
 package dto
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 
+	"github.com/alexander-kolodka/crestic/internal/dbdump"
 	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+	"github.com/alexander-kolodka/crestic/internal/secret"
 )
 
 func ToEntity(cfg Config) (*entity.Config, error) {
-	repos := lo.MapEntries(cfg.Repositories,
-		func(name string, repo Repository) (string, *entity.Repository) {
-			return name, toRepository(name, repo)
-		},
-	)
+	repos := make(map[string]*entity.Repository, len(cfg.Repositories))
+	var repoErrs []error
+	for _, name := range slices.Sorted(maps.Keys(cfg.Repositories)) {
+		r, err := toRepository(name, cfg.Repositories[name])
+		if err != nil {
+			repoErrs = append(repoErrs, err)
+			continue
+		}
+		repos[name] = r
+	}
+
+	err := errors.Join(repoErrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = linkChunkerParamsSources(cfg.Repositories, repos)
+	if err != nil {
+		return nil, err
+	}
 
 	missedRepos := make(map[string]struct{})
+	var jobErrs []error
 
 	jobs := lo.Map(cfg.Jobs,
 		func(job Job, _ int) entity.Job {
@@ -27,7 +51,12 @@ func ToEntity(cfg Config) (*entity.Config, error) {
 					missedRepos[j.To] = struct{}{}
 				}
 
-				return toBackupJob(j, repo)
+				b, err := toBackupJob(j, repo)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				return b
 			case CopyJob:
 				from, ok := repos[j.From]
 				if !ok {
@@ -39,7 +68,55 @@ func ToEntity(cfg Config) (*entity.Config, error) {
 					missedRepos[j.To] = struct{}{}
 				}
 
-				return toCopyJob(j, from, to)
+				c, err := toCopyJob(j, from, to)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				return c
+			case CheckJob:
+				targets, err := toJobRepos(j.Name, j.Repositories, repos, missedRepos)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				m, err := toCheckJob(j, targets)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				return m
+			case PruneJob:
+				targets, err := toJobRepos(j.Name, j.Repositories, repos, missedRepos)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				m, err := toPruneJob(j, targets)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				return m
+			case ForgetJob:
+				targets, err := toJobRepos(j.Name, j.Repositories, repos, missedRepos)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				m, err := toForgetJob(j, targets)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				return m
+			case CommandJob:
+				c, err := toCommandJob(j)
+				if err != nil {
+					jobErrs = append(jobErrs, err)
+				}
+
+				return c
 			default:
 			}
 
@@ -52,49 +129,560 @@ func ToEntity(cfg Config) (*entity.Config, error) {
 		return nil, fmt.Errorf("missed repositories: %s", strings.Join(missed, ", "))
 	}
 
+	err = errors.Join(jobErrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	hcURL, err := toHealthcheckURL(cfg.HealthcheckURL, cfg.Healthcheck)
+	if err != nil {
+		return nil, err
+	}
+
 	return &entity.Config{
-		HealthcheckURL: cfg.HealthcheckURL,
+		HealthcheckURL: hcURL,
+		Healthcheck:    toHealthcheckOptions(cfg.Healthcheck),
 		Repositories:   repos,
 		Jobs:           jobs,
+		Redact:         cfg.Redact,
 	}, nil
 }
 
-func toRepository(name string, repo Repository) *entity.Repository {
+// toHealthcheckURL returns the configured healthcheck URL.
+// A ping key with a slug, optionally on a custom ping URL, is an alternative to the full healthcheck_url.
+func toHealthcheckURL(url string, hc Healthcheck) (string, error) {
+	if hc.PingURL == "" && hc.PingKey == "" && hc.Slug == "" {
+		return url, nil
+	}
+
+	if url != "" {
+		return "", errors.New(
+			"healthcheck_url and healthcheck.ping_url, ping_key or slug cannot be used together",
+		)
+	}
+
+	pingURL, err := healthchecks.SlugURL(hc.PingURL, hc.PingKey, hc.Slug)
+	if err != nil {
+		return "", fmt.Errorf("healthcheck: %w", err)
+	}
+
+	return pingURL, nil
+}
+
+func toHealthcheckOptions(hc Healthcheck) entity.HealthcheckOptions {
+	return entity.HealthcheckOptions{
+		Timeout: hc.Timeout,
+		Retries: hc.Retries,
+		Backoff: hc.Backoff,
+	}
+}
+
+// repositoryVersions are the values accepted by restic init --repository-version.
+var repositoryVersions = []string{"", "1", "2", "latest", "stable"}
+
+func toRepository(name string, repo Repository) (*entity.Repository, error) {
+	sources := lo.Compact([]string{repo.PasswordCMD, repo.PasswordFile, repo.PasswordEnv, repo.Password})
+	if len(sources) > 1 {
+		return nil, fmt.Errorf(
+			"repository %s: password, password_command, password_file and password_env cannot be used together",
+			name,
+		)
+	}
+
+	if repo.Password != "" {
+		_, err := secret.Parse(repo.Password)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: password must be a secret reference: %w", name, err)
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(repo.Env)) {
+		if !secret.IsRef(repo.Env[key]) {
+			continue
+		}
+		_, err := secret.Parse(repo.Env[key])
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: env %s: %w", name, key, err)
+		}
+	}
+
+	if !lo.Contains(repositoryVersions, repo.InitOptions.RepositoryVersion) {
+		return nil, fmt.Errorf(
+			"repository %s: init_options.repository_version must be one of 1, 2, latest or stable, got %q",
+			name,
+			repo.InitOptions.RepositoryVersion,
+		)
+	}
+
+	if repo.CachePassword && repo.PasswordCMD == "" {
+		return nil, fmt.Errorf("repository %s: cache_password requires password_command", name)
+	}
+
 	return &entity.Repository{
 		Name:          name,
 		Path:          repo.Path,
 		PasswordCMD:   repo.PasswordCMD,
+		PasswordFile:  repo.PasswordFile,
+		PasswordEnv:   repo.PasswordEnv,
+		CachePassword: repo.CachePassword,
+		Password:      repo.Password,
+		Env:           repo.Env,
 		ForgetOptions: entity.Options(repo.ForgetOptions),
+		AutoInit:      lo.FromPtrOr(repo.AutoInit, true),
+		InitOptions: entity.InitOptions{
+			RepositoryVersion: repo.InitOptions.RepositoryVersion,
+		},
+	}, nil
+}
+
+// linkChunkerParamsSources resolves init_options.copy_chunker_params_from to the configured repositories.
+func linkChunkerParamsSources(cfgRepos map[string]Repository, repos map[string]*entity.Repository) error {
+	for _, name := range slices.Sorted(maps.Keys(cfgRepos)) {
+		from := cfgRepos[name].InitOptions.CopyChunkerParamsFrom
+		if from == "" {
+			continue
+		}
+
+		if from == name {
+			return fmt.Errorf("repository %s: init_options.copy_chunker_params_from must be another repository", name)
+		}
+
+		src, ok := repos[from]
+		if !ok {
+			return fmt.Errorf("repository %s: init_options.copy_chunker_params_from: unknown repository %s", name, from)
+		}
+
+		repos[name].InitOptions.CopyChunkerParamsFrom = src
 	}
+
+	return nil
 }
 
-func toBackupJob(b BackupJob, repo *entity.Repository) entity.BackupJob {
+func toBackupJob(b BackupJob, repo *entity.Repository) (entity.BackupJob, error) {
+	maxAge, err := toDuration(b.Name, "max_age", b.MaxAge)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
+	timeout, err := toDuration(b.Name, "timeout", b.Timeout)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
+	hooks, err := toHooks(b.Name, b.Hooks)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
+	fromCommand, err := toCommandSource(b)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
+	dumps, err := toDumps(b)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
+	snapshot, err := toFilesystemSnapshot(b)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
+	restoreTest, err := toRestoreTest(b.Name, b.RestoreTest)
+	if err != nil {
+		return entity.BackupJob{}, err
+	}
+
 	return entity.BackupJob{
 		Name:                     b.Name,
 		Cron:                     b.Cron,
 		IgnoreMissingXAttrsError: b.IgnoreMissingXAttrsError,
 		From:                     b.From,
+		FromCommand:              fromCommand,
+		Dumps:                    dumps,
+		Snapshot:                 snapshot,
 		To:                       repo,
 		Options:                  entity.Options(b.Options),
-		Hooks:                    toHooks(b.Hooks),
+		Hooks:                    hooks,
+		Timeout:                  timeout,
+		MaxAge:                   maxAge,
+		RestoreTest:              restoreTest,
+	}, nil
+}
+
+// toCommandSource validates the backup sources and returns the command source, if any.
+// Exactly one of from, from_command and sources must be set.
+func toCommandSource(b BackupJob) (*entity.CommandSource, error) {
+	set := lo.Count([]bool{len(b.From) > 0, b.FromCommand != nil, len(b.Sources) > 0}, true)
+	if set == 0 {
+		return nil, fmt.Errorf("job %s: one of from, from_command or sources is required", b.Name)
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("job %s: from, from_command and sources cannot be used together", b.Name)
+	}
+
+	if b.FromCommand == nil {
+		return nil, nil //nolint:nilnil // from_command is optional
+	}
+
+	if b.FromCommand.Command == "" {
+		return nil, fmt.Errorf("job %s: from_command.command is required", b.Name)
+	}
+
+	if b.RestoreTest != nil {
+		return nil, fmt.Errorf("job %s: restore_test is not supported with from_command", b.Name)
+	}
+
+	filename := b.FromCommand.Filename
+	if filename == "" {
+		filename = b.Name
+	}
+
+	if strings.Contains(filename, "/") {
+		return nil, fmt.Errorf("job %s: from_command.filename must not contain '/'", b.Name)
+	}
+
+	return &entity.CommandSource{
+		Command:  b.FromCommand.Command,
+		Filename: filename,
+	}, nil
+}
+
+// toDumps converts the database sources of a backup job, one dump per database.
+func toDumps(b BackupJob) ([]entity.Dump, error) {
+	if len(b.Sources) == 0 {
+		return nil, nil
 	}
+
+	if b.RestoreTest != nil {
+		return nil, fmt.Errorf("job %s: restore_test is not supported with sources", b.Name)
+	}
+
+	var dumps []entity.Dump
+	for i, src := range b.Sources {
+		d, err := toSourceDumps(src)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: sources[%d]: %w", b.Name, i, err)
+		}
+		dumps = append(dumps, d...)
+	}
+
+	return dumps, nil
+}
+
+func toSourceDumps(src Source) ([]entity.Dump, error) {
+	switch lo.Count([]bool{src.Postgres != nil, src.MySQL != nil, src.SQLite != nil}, true) {
+	case 0:
+		return nil, errors.New("one of postgres, mysql or sqlite is required")
+	case 1:
+	default:
+		return nil, errors.New("only one of postgres, mysql or sqlite can be set")
+	}
+
+	switch {
+	case src.Postgres != nil:
+		return toDatabaseDumps(src.Postgres.Databases, func(db string) (entity.Dump, error) {
+			return dbdump.Postgres(src.Postgres.DSN, db)
+		})
+	case src.MySQL != nil:
+		opts := dbdump.MySQLOptions{
+			Host:         src.MySQL.Host,
+			Port:         src.MySQL.Port,
+			User:         src.MySQL.User,
+			DefaultsFile: src.MySQL.DefaultsFile,
+		}
+		return toDatabaseDumps(src.MySQL.Databases, func(db string) (entity.Dump, error) {
+			return dbdump.MySQL(opts, db)
+		})
+	default:
+		d, err := dbdump.SQLite(src.SQLite.Path)
+		if err != nil {
+			return nil, err
+		}
+		return []entity.Dump{d}, nil
+	}
+}
+
+func toDatabaseDumps(databases []string, dump func(db string) (entity.Dump, error)) ([]entity.Dump, error) {
+	if len(databases) == 0 {
+		return nil, errors.New("at least one database is required")
+	}
+
+	dumps := make([]entity.Dump, 0, len(databases))
+	for _, db := range databases {
+		d, err := dump(db)
+		if err != nil {
+			return nil, err
+		}
+		dumps = append(dumps, d)
+	}
+
+	return dumps, nil
+}
+
+// toFilesystemSnapshot validates the filesystem snapshot of a backup job.
+// All sources must be inside the snapshotted filesystem.
+func toFilesystemSnapshot(b BackupJob) (*entity.FilesystemSnapshot, error) {
+	s := b.Snapshot
+	if s == nil {
+		return nil, nil //nolint:nilnil // snapshot is optional
+	}
+
+	if len(b.From) == 0 {
+		return nil, fmt.Errorf("job %s: snapshot requires from", b.Name)
+	}
+
+	var fs entity.FilesystemSnapshot
+	switch s.Type {
+	case entity.SnapshotBtrfs:
+		if s.Subvolume == "" {
+			return nil, fmt.Errorf("job %s: snapshot.subvolume is required for btrfs", b.Name)
+		}
+		fs = entity.FilesystemSnapshot{Type: s.Type, Source: s.Subvolume, Mountpoint: s.Subvolume}
+	case entity.SnapshotZFS:
+		if s.Dataset == "" || s.Mountpoint == "" {
+			return nil, fmt.Errorf("job %s: snapshot.dataset and snapshot.mountpoint are required for zfs", b.Name)
+		}
+		fs = entity.FilesystemSnapshot{Type: s.Type, Source: s.Dataset, Mountpoint: s.Mountpoint}
+	case entity.SnapshotLVM:
+		if s.Volume == "" || s.Mountpoint == "" || s.Size == "" {
+			return nil, fmt.Errorf(
+				"job %s: snapshot.volume, snapshot.mountpoint and snapshot.size are required for lvm", b.Name,
+			)
+		}
+		if strings.Count(s.Volume, "/") != 1 {
+			return nil, fmt.Errorf("job %s: snapshot.volume must be in the form vg/lv, got %q", b.Name, s.Volume)
+		}
+		fs = entity.FilesystemSnapshot{Type: s.Type, Source: s.Volume, Mountpoint: s.Mountpoint, Size: s.Size}
+	default:
+		return nil, fmt.Errorf(
+			"job %s: snapshot.type must be %q, %q or %q, got %q",
+			b.Name, entity.SnapshotBtrfs, entity.SnapshotZFS, entity.SnapshotLVM, s.Type,
+		)
+	}
+
+	fs.Mountpoint = filepath.Clean(fs.Mountpoint)
+	for _, from := range b.From {
+		rel, err := filepath.Rel(fs.Mountpoint, from)
+		if !filepath.IsAbs(from) || err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("job %s: %s is not inside the snapshot mountpoint %s", b.Name, from, fs.Mountpoint)
+		}
+	}
+
+	return &fs, nil
 }
 
-func toCopyJob(c CopyJob, from, to *entity.Repository) entity.CopyJob {
+func toCopyJob(c CopyJob, from, to *entity.Repository) (entity.CopyJob, error) {
+	maxAge, err := toDuration(c.Name, "max_age", c.MaxAge)
+	if err != nil {
+		return entity.CopyJob{}, err
+	}
+
+	timeout, err := toDuration(c.Name, "timeout", c.Timeout)
+	if err != nil {
+		return entity.CopyJob{}, err
+	}
+
+	hooks, err := toHooks(c.Name, c.Hooks)
+	if err != nil {
+		return entity.CopyJob{}, err
+	}
+
 	return entity.CopyJob{
 		Name:    c.Name,
 		Cron:    c.Cron,
 		From:    from,
 		To:      to,
 		Options: entity.Options(c.Options),
-		Hooks:   toHooks(c.Hooks),
+		Hooks:   hooks,
+		Timeout: timeout,
+		MaxAge:  maxAge,
+	}, nil
+}
+
+// toJobRepos resolves the repositories targeted by a maintenance job.
+// Unknown repository names are recorded in missed.
+func toJobRepos(
+	jobName string,
+	names []string,
+	repos map[string]*entity.Repository,
+	missed map[string]struct{},
+) ([]*entity.Repository, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("job %s: at least one repository is required", jobName)
+	}
+
+	return lo.FilterMap(names, func(name string, _ int) (*entity.Repository, bool) {
+		repo, ok := repos[name]
+		if !ok {
+			missed[name] = struct{}{}
+		}
+		return repo, ok
+	}), nil
+}
+
+func toCheckJob(c CheckJob, repos []*entity.Repository) (entity.CheckJob, error) {
+	timeout, err := toDuration(c.Name, "timeout", c.Timeout)
+	if err != nil {
+		return entity.CheckJob{}, err
+	}
+
+	hooks, err := toHooks(c.Name, c.Hooks)
+	if err != nil {
+		return entity.CheckJob{}, err
+	}
+
+	return entity.CheckJob{
+		Name:           c.Name,
+		Cron:           c.Cron,
+		Repos:          repos,
+		ReadDataSubset: c.ReadDataSubset,
+		Hooks:          hooks,
+		Timeout:        timeout,
+	}, nil
+}
+
+func toPruneJob(p PruneJob, repos []*entity.Repository) (entity.PruneJob, error) {
+	timeout, err := toDuration(p.Name, "timeout", p.Timeout)
+	if err != nil {
+		return entity.PruneJob{}, err
 	}
+
+	hooks, err := toHooks(p.Name, p.Hooks)
+	if err != nil {
+		return entity.PruneJob{}, err
+	}
+
+	return entity.PruneJob{
+		Name:          p.Name,
+		Cron:          p.Cron,
+		Repos:         repos,
+		MaxUnused:     p.MaxUnused,
+		MaxRepackSize: p.MaxRepackSize,
+		Hooks:         hooks,
+		Timeout:       timeout,
+	}, nil
+}
+
+func toForgetJob(f ForgetJob, repos []*entity.Repository) (entity.ForgetJob, error) {
+	timeout, err := toDuration(f.Name, "timeout", f.Timeout)
+	if err != nil {
+		return entity.ForgetJob{}, err
+	}
+
+	hooks, err := toHooks(f.Name, f.Hooks)
+	if err != nil {
+		return entity.ForgetJob{}, err
+	}
+
+	return entity.ForgetJob{
+		Name:    f.Name,
+		Cron:    f.Cron,
+		Repos:   repos,
+		Options: entity.Options(f.Options),
+		Hooks:   hooks,
+		Timeout: timeout,
+	}, nil
+}
+
+func toCommandJob(c CommandJob) (entity.CommandJob, error) {
+	if len(c.Run) == 0 {
+		return entity.CommandJob{}, fmt.Errorf("job %s: run must contain at least one command", c.Name)
+	}
+
+	timeout, err := toDuration(c.Name, "timeout", c.Timeout)
+	if err != nil {
+		return entity.CommandJob{}, err
+	}
+
+	hooks, err := toHooks(c.Name, c.Hooks)
+	if err != nil {
+		return entity.CommandJob{}, err
+	}
+
+	return entity.CommandJob{
+		Name:    c.Name,
+		Cron:    c.Cron,
+		Run:     c.Run,
+		Dir:     c.Dir,
+		Env:     c.Env,
+		Timeout: timeout,
+		Hooks:   hooks,
+	}, nil
+}
+
+// toDuration parses an optional duration field of a job, e.g. max_age or timeout.
+func toDuration(jobName, field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	d, err := entity.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("job %s: %s: %w", jobName, field, err)
+	}
+
+	return d, nil
 }
 
-func toHooks(h Hooks) entity.Hooks {
+// defaultRestoreTestSample is the number of files verified when neither sample nor paths are set.
+const defaultRestoreTestSample = 10
+
+func toRestoreTest(jobName string, rt *RestoreTest) (*entity.RestoreTest, error) {
+	if rt == nil {
+		return nil, nil //nolint:nilnil // restore test is optional
+	}
+
+	if rt.Sample < 0 {
+		return nil, fmt.Errorf("job %s: restore_test.sample must not be negative", jobName)
+	}
+
+	sample := rt.Sample
+	if sample == 0 && len(rt.Paths) == 0 {
+		sample = defaultRestoreTestSample
+	}
+
+	compare := rt.Compare
+	if compare == "" {
+		compare = entity.CompareSource
+	}
+
+	switch compare {
+	case entity.CompareSource:
+		if rt.Manifest != "" {
+			return nil, fmt.Errorf("job %s: restore_test.manifest requires compare: manifest", jobName)
+		}
+	case entity.CompareManifest:
+		if rt.Manifest == "" {
+			return nil, fmt.Errorf("job %s: restore_test.manifest is required with compare: manifest", jobName)
+		}
+	default:
+		return nil, fmt.Errorf(
+			"job %s: restore_test.compare must be %q or %q, got %q",
+			jobName, entity.CompareSource, entity.CompareManifest, compare,
+		)
+	}
+
+	return &entity.RestoreTest{
+		Sample:      sample,
+		Paths:       rt.Paths,
+		Compare:     compare,
+		Manifest:    rt.Manifest,
+		AfterBackup: rt.AfterBackup,
+	}, nil
+}
+
+func toHooks(jobName string, h Hooks) (entity.Hooks, error) {
+	timeout, err := toDuration(jobName, "hooks.timeout", h.Timeout)
+	if err != nil {
+		return entity.Hooks{}, err
+	}
+
 	return entity.Hooks{
 		Before:  h.Before,
 		Failure: h.Failure,
 		Success: h.Success,
-	}
+		Timeout: timeout,
+	}, nil
 }
@@ -1,35 +1,78 @@
 package entity
 
+import (
+	"time"
+
+	"github.com/samber/lo"
+)
+
 // Config represents the top-level configuration for crestic.
 // It contains all backup/copy jobs, repository definitions, and global settings.
 type Config struct {
-	Jobs           Jobs                   // List of backup and copy jobs to execute
+	Jobs           Jobs                   // List of backup, copy and maintenance jobs to execute
 	Repositories   map[string]*Repository // Map of repository names to repository configs
 	HealthcheckURL string                 // Global healthcheck URL for monitoring (can be overridden per job)
+	Healthcheck    HealthcheckOptions     // Healthcheck client settings (timeout, retries, backoff)
+	Redact         []string               // Additional secret values masked in logs and printed commands
+}
+
+// Secrets returns the configured values that must not appear in logs:
+// explicitly listed values, healthcheck ping URLs and environment values of command jobs and repositories.
+func (c *Config) Secrets() []string {
+	secrets := append([]string{c.HealthcheckURL}, c.Redact...)
+	for _, j := range c.Jobs {
+		secrets = append(secrets, j.GetHealthcheckURL())
+
+		cj, ok := j.(CommandJob)
+		if ok {
+			secrets = append(secrets, lo.Values(cj.Env)...)
+		}
+	}
+
+	for _, r := range c.Repositories {
+		secrets = append(secrets, lo.Values(r.Env)...)
+	}
+
+	return lo.Compact(secrets)
+}
+
+// HealthcheckOptions configures how healthcheck pings are delivered.
+// Zero values mean "use the client defaults".
+type HealthcheckOptions struct {
+	Timeout time.Duration // HTTP timeout for a single ping request
+	Retries int           // Maximum number of attempts per ping
+	Backoff time.Duration // Initial delay between attempts, doubled after each retry
 }
 
 // Jobs is a list of Job interfaces representing different types of backup operations.
 type Jobs []Job
 
-// Job is the interface that all job types (backup, copy) must implement.
+// Job is the interface that all job types (backup, copy, maintenance, command) must implement.
 // It provides common methods for accessing job properties.
 type Job interface {
 	GetName() string           // Returns the unique name of the job
 	GetHooks() Hooks           // Returns the lifecycle hooks for the job
 	GetHealthcheckURL() string // Returns the healthcheck URL for monitoring
 	GetCron() string           // Returns the cron expression for scheduling
+	GetTimeout() time.Duration // Returns the maximum run time of the job (0 = no limit)
 }
 
 // BackupJob represents a backup operation that backs up directories to a repository.
 type BackupJob struct {
-	Name                     string      // Unique identifier for this backup job
-	HealthcheckURL           string      // Optional healthcheck URL (overrides global setting)
-	Cron                     string      // Cron expression for scheduling (e.g., "0 2 * * *")
-	IgnoreMissingXAttrsError bool        // If true, ignore extended attributes errors during backup
-	From                     []string    // List of source directories to back up
-	To                       *Repository // Target repository for storing backups
-	Options                  Options     // Additional restic options (tags, excludes, etc.)
-	Hooks                    Hooks       // Lifecycle hooks (before, success, failure)
+	Name                     string              // Unique identifier for this backup job
+	HealthcheckURL           string              // Optional healthcheck URL (overrides global setting)
+	Cron                     string              // Cron expression for scheduling (e.g., "0 2 * * *")
+	IgnoreMissingXAttrsError bool                // If true, ignore extended attributes errors during backup
+	From                     []string            // List of source directories to back up
+	FromCommand              *CommandSource      // Command whose stdout is backed up instead of From (nil = not used)
+	Dumps                    []Dump              // Databases backed up instead of From, one snapshot each
+	Snapshot                 *FilesystemSnapshot // Filesystem snapshot the sources are read from (nil = live files)
+	To                       *Repository         // Target repository for storing backups
+	Options                  Options             // Additional restic options (tags, excludes, etc.)
+	Hooks                    Hooks               // Lifecycle hooks (before, success, failure)
+	Timeout                  time.Duration       // Maximum run time of the job, hooks excluded (0 = no limit)
+	MaxAge                   time.Duration       // Maximum allowed age of the latest snapshot (0 = not checked)
+	RestoreTest              *RestoreTest        // Optional restore verification (nil = not configured)
 }
 
 // GetName returns the name of the backup job.
@@ -52,16 +95,23 @@ func (b BackupJob) GetCron() string {
 	return b.Cron
 }
 
+// GetTimeout returns the maximum run time of this backup job.
+func (b BackupJob) GetTimeout() time.Duration {
+	return b.Timeout
+}
+
 // CopyJob represents a copy operation that replicates snapshots between repositories.
 // This is useful for creating off-site backups or maintaining multiple backup copies.
 type CopyJob struct {
-	Name           string      // Unique identifier for this copy job
-	HealthcheckURL string      // Optional healthcheck URL (overrides global setting)
-	Cron           string      // Cron expression for scheduling (e.g., "0 3 * * *")
-	From           *Repository // Source repository to copy from
-	To             *Repository // Destination repository to copy to
-	Options        Options     // Additional restic copy options (tags, filters, etc.)
-	Hooks          Hooks       // Lifecycle hooks (before, success, failure)
+	Name           string        // Unique identifier for this copy job
+	HealthcheckURL string        // Optional healthcheck URL (overrides global setting)
+	Cron           string        // Cron expression for scheduling (e.g., "0 3 * * *")
+	From           *Repository   // Source repository to copy from
+	To             *Repository   // Destination repository to copy to
+	Options        Options       // Additional restic copy options (tags, filters, etc.)
+	Hooks          Hooks         // Lifecycle hooks (before, success, failure)
+	Timeout        time.Duration // Maximum run time of the job, hooks excluded (0 = no limit)
+	MaxAge         time.Duration // Maximum allowed age of the latest copied snapshot (0 = not checked)
 }
 
 // GetName returns the name of the copy job.
@@ -85,19 +135,310 @@ func (c CopyJob) GetCron() string {
 	return c.Cron
 }
 
+// GetTimeout returns the maximum run time of this copy job.
+func (c CopyJob) GetTimeout() time.Duration {
+	return c.Timeout
+}
+
 // Repository represents a restic backup repository configuration.
 // It defines where backups are stored and how to access them.
 type Repository struct {
-	Name          string  // Unique name for this repository
-	Path          string  // Repository path or URL (local path, sftp://, s3://, rclone:, etc.)
-	PasswordCMD   string  // Shell command that outputs the repository password
-	ForgetOptions Options // Retention policy options (keep-daily, keep-weekly, etc.)
+	Name          string            // Unique name for this repository
+	Path          string            // Repository path or URL (local path, sftp://, s3://, rclone:, etc.)
+	PasswordCMD   string            // Shell command that outputs the repository password
+	PasswordFile  string            // File containing the repository password (alternative to PasswordCMD)
+	PasswordEnv   string            // Environment variable containing the repository password (alternative to PasswordCMD)
+	CachePassword bool              // If true, PasswordCMD runs once per process instead of on every restic invocation
+	Password      string            // Secret reference (secret://...) resolving to the password (alternative to PasswordCMD)
+	Env           map[string]string // Environment of restic commands, e.g. backend credentials; values may be secret references
+	ForgetOptions Options           // Retention policy options (keep-daily, keep-weekly, etc.)
+	AutoInit      bool              // If true, the repository is initialized when a job finds it missing
+	InitOptions   InitOptions       // Options used when the repository is initialized
+}
+
+// InitOptions configure how a repository is created by `restic init`.
+type InitOptions struct {
+	RepositoryVersion     string      // restic --repository-version (1, 2, latest or stable; empty = restic default)
+	CopyChunkerParamsFrom *Repository // Repository whose chunker parameters are copied (nil = new parameters)
+}
+
+// CommandSource is a backup source read from the standard output of a shell command,
+// e.g. a database dump. The backup fails if the command exits with a non-zero status.
+type CommandSource struct {
+	Command  string // Shell command producing the data to back up
+	Filename string // File name of the data inside the snapshot
+}
+
+// Path returns the path of the backed up data inside the snapshot.
+func (c CommandSource) Path() string {
+	return "/" + c.Filename
+}
+
+// Filesystem snapshot types.
+const (
+	SnapshotBtrfs = "btrfs"
+	SnapshotZFS   = "zfs"
+	SnapshotLVM   = "lvm"
+)
+
+// FilesystemSnapshot configures a read-only filesystem snapshot taken before a backup,
+// so all sources are backed up in a consistent state.
+type FilesystemSnapshot struct {
+	Type       string // SnapshotBtrfs, SnapshotZFS or SnapshotLVM
+	Source     string // Btrfs subvolume path, ZFS dataset or LVM logical volume (vg/lv)
+	Mountpoint string // Directory Source is mounted at; all job sources must be inside it
+	Size       string // Size reserved for changes during the backup (LVM only, e.g. "1G")
+}
+
+// Restore test comparison sources.
+const (
+	CompareSource   = "source"   // Compare restored files with the live source files
+	CompareManifest = "manifest" // Compare restored files with a recorded checksum manifest
+)
+
+// RestoreTest configures automated restore verification of a backup job.
+// Selected files of the latest snapshot are restored to a temporary directory
+// and their checksums are compared with the source or a manifest.
+type RestoreTest struct {
+	Sample      int      // Number of randomly chosen files to verify
+	Paths       []string // Paths that are always verified (absolute or relative to the job sources)
+	Compare     string   // CompareSource or CompareManifest
+	Manifest    string   // Checksum file in sha256sum format (used with CompareManifest)
+	AfterBackup bool     // Run the restore test after every successful backup
 }
 
 // Hooks defines lifecycle hooks that run at different stages of a job.
 // Hooks are shell commands executed in the specified order.
 type Hooks struct {
-	Before  []string // Commands to run before the job starts (if any fail, job is aborted)
-	Failure []string // Commands to run if the job fails
-	Success []string // Commands to run if the job succeeds
+	Before  []string      // Commands to run before the job starts (if any fail, job is aborted)
+	Failure []string      // Commands to run if the job fails
+	Success []string      // Commands to run if the job succeeds
+	Timeout time.Duration // Maximum run time of every single hook command (0 = no limit)
+}
+
+// SnapshotFilter returns the filter selecting snapshots created by this backup job.
+// The host is only restricted if it's set explicitly in the job options.
+// A job with sources writes a snapshot per database, selected by the filter of ForDump.
+func (b BackupJob) SnapshotFilter() SnapshotFilter {
+	paths := b.From
+	if b.FromCommand != nil {
+		paths = []string{b.FromCommand.Path()}
+	}
+
+	return SnapshotFilter{
+		Host:  b.Options.String("host"),
+		Paths: paths,
+		Tags:  b.Options.Strings("tag"),
+	}
+}
+
+// SnapshotFilter returns the filter selecting snapshots copied by this job
+// to the destination repository.
+func (c CopyJob) SnapshotFilter() SnapshotFilter {
+	return SnapshotFilter{
+		Host:  c.Options.String("host"),
+		Paths: c.Options.Strings("path"),
+		Tags:  c.Options.Strings("tag"),
+	}
+}
+
+// CheckJob represents a scheduled integrity check of one or more repositories.
+type CheckJob struct {
+	Name           string        // Unique identifier for this check job
+	HealthcheckURL string        // Optional healthcheck URL (overrides global setting)
+	Cron           string        // Cron expression for scheduling (e.g., "0 5 * * 0")
+	Repos          []*Repository // Repositories to check
+	ReadDataSubset string        // Optional subset of pack files to read (e.g., "5%", "1/10", "500M")
+	Hooks          Hooks         // Lifecycle hooks (before, success, failure)
+	Timeout        time.Duration // Maximum run time of the job, hooks excluded (0 = no limit)
+}
+
+// GetName returns the name of the check job.
+func (c CheckJob) GetName() string {
+	return c.Name
+}
+
+// GetHooks returns the lifecycle hooks configured for this check job.
+func (c CheckJob) GetHooks() Hooks {
+	return c.Hooks
+}
+
+// GetHealthcheckURL returns the healthcheck URL for monitoring this check job.
+func (c CheckJob) GetHealthcheckURL() string {
+	return c.HealthcheckURL
+}
+
+// GetCron returns the cron expression for scheduling this check job.
+func (c CheckJob) GetCron() string {
+	return c.Cron
+}
+
+// GetTimeout returns the maximum run time of this check job.
+func (c CheckJob) GetTimeout() time.Duration {
+	return c.Timeout
+}
+
+// Options returns the restic check options of this job.
+func (c CheckJob) Options() Options {
+	opts := Options{}
+	if c.ReadDataSubset != "" {
+		opts["read-data-subset"] = c.ReadDataSubset
+	}
+	return opts
+}
+
+// PruneJob represents a scheduled removal of unreferenced data from one or more repositories.
+type PruneJob struct {
+	Name           string        // Unique identifier for this prune job
+	HealthcheckURL string        // Optional healthcheck URL (overrides global setting)
+	Cron           string        // Cron expression for scheduling (e.g., "0 6 * * 0")
+	Repos          []*Repository // Repositories to prune
+	MaxUnused      string        // Optional tolerated amount of unused space (e.g., "5%", "1G", "unlimited")
+	MaxRepackSize  string        // Optional maximum size of data to repack (e.g., "2G")
+	Hooks          Hooks         // Lifecycle hooks (before, success, failure)
+	Timeout        time.Duration // Maximum run time of the job, hooks excluded (0 = no limit)
+}
+
+// GetName returns the name of the prune job.
+func (p PruneJob) GetName() string {
+	return p.Name
+}
+
+// GetHooks returns the lifecycle hooks configured for this prune job.
+func (p PruneJob) GetHooks() Hooks {
+	return p.Hooks
+}
+
+// GetHealthcheckURL returns the healthcheck URL for monitoring this prune job.
+func (p PruneJob) GetHealthcheckURL() string {
+	return p.HealthcheckURL
+}
+
+// GetCron returns the cron expression for scheduling this prune job.
+func (p PruneJob) GetCron() string {
+	return p.Cron
+}
+
+// GetTimeout returns the maximum run time of this prune job.
+func (p PruneJob) GetTimeout() time.Duration {
+	return p.Timeout
+}
+
+// Options returns the restic prune options of this job.
+func (p PruneJob) Options() Options {
+	opts := Options{}
+	if p.MaxUnused != "" {
+		opts["max-unused"] = p.MaxUnused
+	}
+	if p.MaxRepackSize != "" {
+		opts["max-repack-size"] = p.MaxRepackSize
+	}
+	return opts
+}
+
+// ForgetJob represents a scheduled application of the retention policy to one or more repositories.
+// Each repository's forget_options are used, with the job options taking precedence.
+type ForgetJob struct {
+	Name           string        // Unique identifier for this forget job
+	HealthcheckURL string        // Optional healthcheck URL (overrides global setting)
+	Cron           string        // Cron expression for scheduling (e.g., "0 4 * * *")
+	Repos          []*Repository // Repositories to apply the retention policy to
+	Options        Options       // Additional restic forget options (override repository forget_options)
+	Hooks          Hooks         // Lifecycle hooks (before, success, failure)
+	Timeout        time.Duration // Maximum run time of the job, hooks excluded (0 = no limit)
+}
+
+// GetName returns the name of the forget job.
+func (f ForgetJob) GetName() string {
+	return f.Name
+}
+
+// GetHooks returns the lifecycle hooks configured for this forget job.
+func (f ForgetJob) GetHooks() Hooks {
+	return f.Hooks
+}
+
+// GetHealthcheckURL returns the healthcheck URL for monitoring this forget job.
+func (f ForgetJob) GetHealthcheckURL() string {
+	return f.HealthcheckURL
+}
+
+// GetCron returns the cron expression for scheduling this forget job.
+func (f ForgetJob) GetCron() string {
+	return f.Cron
+}
+
+// GetTimeout returns the maximum run time of this forget job.
+func (f ForgetJob) GetTimeout() time.Duration {
+	return f.Timeout
+}
+
+// CommandJob represents an arbitrary scheduled script that runs with the crestic job lifecycle
+// (hooks, healthchecks, run history), e.g. a database dump or an rclone sync.
+type CommandJob struct {
+	Name           string            // Unique identifier for this command job
+	HealthcheckURL string            // Optional healthcheck URL (overrides global setting)
+	Cron           string            // Cron expression for scheduling (e.g., "0 1 * * *")
+	Run            []string          // Shell commands executed in order; the first failure aborts the job
+	Dir            string            // Optional working directory of the commands
+	Env            map[string]string // Additional environment variables of the commands
+	Timeout        time.Duration     // Maximum duration of all commands together (0 = no limit)
+	Hooks          Hooks             // Lifecycle hooks (before, success, failure)
+}
+
+// GetName returns the name of the command job.
+func (c CommandJob) GetName() string {
+	return c.Name
+}
+
+// GetHooks returns the lifecycle hooks configured for this command job.
+func (c CommandJob) GetHooks() Hooks {
+	return c.Hooks
+}
+
+// GetHealthcheckURL returns the healthcheck URL for monitoring this command job.
+func (c CommandJob) GetHealthcheckURL() string {
+	return c.HealthcheckURL
+}
+
+// GetCron returns the cron expression for scheduling this command job.
+func (c CommandJob) GetCron() string {
+	return c.Cron
+}
+
+// GetTimeout returns the maximum run time of this command job.
+func (c CommandJob) GetTimeout() time.Duration {
+	return c.Timeout
+}
+
+// Database dump kinds.
+const (
+	DumpPostgres = "postgres"
+	DumpMySQL    = "mysql"
+	DumpSQLite   = "sqlite"
+)
+
+// Dump is a database backed up from the output of its dump command.
+// Every dump is stored in its own snapshot.
+type Dump struct {
+	Kind     string        // DumpPostgres, DumpMySQL or DumpSQLite
+	Database string        // Database name (file name for SQLite)
+	Source   CommandSource // Command producing the dump
+}
+
+// Tags returns the tags added to the snapshot of the dump.
+func (d Dump) Tags() []string {
+	return []string{d.Kind, "db:" + d.Database}
+}
+
+// ForDump returns the job backing up a single database dump.
+// The dump tags are added to the job's tags.
+func (b BackupJob) ForDump(d Dump) BackupJob {
+	tags := lo.ToAnySlice(append(b.Options.Strings("tag"), d.Tags()...))
+
+	b.From = nil
+	b.Dumps = nil
+	b.FromCommand = &d.Source
+	b.Options = b.Options.Merge(Options{"tag": tags})
+	return b
 }
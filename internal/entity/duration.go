@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses a duration string such as "90m", "36h", "7d" or "2w".
+// In addition to time.ParseDuration units it accepts whole days (d) and weeks (w).
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	const (
+		day  = 24 * time.Hour
+		week = 7 * day
+	)
+
+	units := map[string]time.Duration{
+		"d": day,
+		"w": week,
+	}
+
+	for suffix, unit := range units {
+		n, ok := strings.CutSuffix(s, suffix)
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.Atoi(n)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		return time.Duration(v) * unit, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	return d, nil
+}
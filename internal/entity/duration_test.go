@@ -0,0 +1,41 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{in: "90m", expected: 90 * time.Minute},
+		{in: "36h", expected: 36 * time.Hour},
+		{in: "7d", expected: 7 * 24 * time.Hour},
+		{in: "2w", expected: 14 * 24 * time.Hour},
+		{in: " 1d ", expected: 24 * time.Hour},
+		{in: "1.5d", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := entity.ParseDuration(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"time"
 
 	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/redact"
 )
 
 type JobResults struct {
 	SuccessJobs []SuccessJob `json:"successJobs,omitempty"`
 	FailedJobs  []FailedJob  `json:"failedJobs,omitempty"`
+	Interrupted string       `json:"interrupted,omitempty"` // Reason the run was stopped early, e.g. by a signal
 }
 
 type SuccessJob struct {
@@ -32,7 +35,7 @@ func NewJobResults() *JobResults {
 }
 
 func (r *JobResults) ErrorMsg() string {
-	if len(r.FailedJobs) == 0 {
+	if !r.HasErrors() {
 		return ""
 	}
 
@@ -40,11 +43,20 @@ func (r *JobResults) ErrorMsg() string {
 		return fmt.Sprintf("%s elapsed: %s failed: %s", job.Name, job.Elapsed, job.Error)
 	})
 
+	if r.Interrupted != "" {
+		errs = append(errs, r.Interrupted)
+	}
+
 	return strings.Join(errs, ";\n")
 }
 
 func (r *JobResults) HasErrors() bool {
-	return len(r.FailedJobs) > 0
+	return len(r.FailedJobs) > 0 || r.Interrupted != ""
+}
+
+// Interrupt marks the run as stopped early for the given reason.
+func (r *JobResults) Interrupt(reason string) {
+	r.Interrupted = reason
 }
 
 func (r *JobResults) Add(jobName string, elapsed time.Duration, err error) {
@@ -67,6 +79,6 @@ func (r *JobResults) failed(job string, elapsed time.Duration, err error) {
 	r.FailedJobs = append(r.FailedJobs, FailedJob{
 		Name:    job,
 		Elapsed: elapsed.String(),
-		Error:   err.Error(),
+		Error:   redact.String(err.Error()),
 	})
 }
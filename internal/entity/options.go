@@ -43,6 +43,51 @@ func (opts Options) Merge(other Options) Options {
 	return result
 }
 
+// String returns the value of a scalar option, accepting the flag with or without leading dashes.
+// Returns empty string if the option is not set.
+func (opts Options) String(flag string) string {
+	v, ok := opts.lookup(flag)
+	if !ok {
+		return ""
+	}
+
+	return valueToString(v)
+}
+
+// Strings returns the values of a repeatable option such as tag or exclude.
+// A scalar value is returned as a single-element slice. Comma-separated values are not split.
+func (opts Options) Strings(flag string) []string {
+	v, ok := opts.lookup(flag)
+	if !ok {
+		return nil
+	}
+
+	arr, ok := v.([]any)
+	if !ok {
+		s := valueToString(v)
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+
+	return lo.FilterMap(arr, func(item any, _ int) (string, bool) {
+		s := valueToString(item)
+		return s, s != ""
+	})
+}
+
+func (opts Options) lookup(flag string) (any, bool) {
+	flag = strings.TrimLeft(flag, "-")
+	for f, v := range opts {
+		if strings.TrimLeft(f, "-") == flag {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
 // valueToString converts any value to string.
 func valueToString(v any) string {
 	switch val := v.(type) {
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// SnapshotFilter selects snapshots by host, paths and tags.
+// Empty fields do not restrict the selection.
+type SnapshotFilter struct {
+	Host  string   // Hostname the snapshot was created on
+	Paths []string // Snapshot must contain all of these paths, it may have others too
+	Tags  []string // Snapshot must carry all of these tags
+}
+
+// ToArgs converts the filter to restic --host/--path/--tag arguments.
+// All tags are joined into a single --tag value, so restic requires every one of them.
+func (f SnapshotFilter) ToArgs() []string {
+	var args []string
+
+	if f.Host != "" {
+		args = append(args, "--host", f.Host)
+	}
+
+	for _, p := range f.Paths {
+		args = append(args, "--path", p)
+	}
+
+	if len(f.Tags) > 0 {
+		args = append(args, "--tag", strings.Join(f.Tags, ","))
+	}
+
+	return args
+}
+
+// SnapshotQuery selects snapshots in a single repository.
+type SnapshotQuery struct {
+	Repo   *Repository
+	Job    string // Name of the job the filter was derived from, if any, see SnapshotQueries
+	Filter SnapshotFilter
+}
+
+// SnapshotQueries returns the queries selecting snapshots written by the job.
+// A job with sources writes a snapshot per database, so it gets a query per database
+// named "<job>/<database>". ok is false for jobs that don't produce snapshots.
+func SnapshotQueries(j Job) ([]SnapshotQuery, bool) {
+	switch v := j.(type) {
+	case BackupJob:
+		if len(v.Dumps) > 0 {
+			return lo.Map(v.Dumps, func(d Dump, _ int) SnapshotQuery {
+				return SnapshotQuery{Repo: v.To, Job: v.Name + "/" + d.Database, Filter: v.ForDump(d).SnapshotFilter()}
+			}), true
+		}
+		return []SnapshotQuery{{Repo: v.To, Job: v.Name, Filter: v.SnapshotFilter()}}, true
+	case CopyJob:
+		return []SnapshotQuery{{Repo: v.To, Job: v.Name, Filter: v.SnapshotFilter()}}, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,59 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+func TestSnapshotQueriesPerDatabase(t *testing.T) {
+	repo := &entity.Repository{Name: "nas"}
+	job := entity.BackupJob{
+		Name: "db",
+		To:   repo,
+		Dumps: []entity.Dump{
+			{Kind: entity.DumpPostgres, Database: "app", Source: entity.CommandSource{Filename: "app.dump"}},
+			{Kind: entity.DumpMySQL, Database: "shop", Source: entity.CommandSource{Filename: "shop.sql"}},
+		},
+	}
+
+	queries, ok := entity.SnapshotQueries(job)
+	require.True(t, ok)
+
+	assert.Equal(t, []entity.SnapshotQuery{
+		{
+			Repo:   repo,
+			Job:    "db/app",
+			Filter: entity.SnapshotFilter{Paths: []string{"/app.dump"}, Tags: []string{"postgres", "db:app"}},
+		},
+		{
+			Repo:   repo,
+			Job:    "db/shop",
+			Filter: entity.SnapshotFilter{Paths: []string{"/shop.sql"}, Tags: []string{"mysql", "db:shop"}},
+		},
+	}, queries)
+}
+
+func TestSnapshotQueries(t *testing.T) {
+	repo := &entity.Repository{Name: "nas"}
+	job := entity.BackupJob{
+		Name:    "docs",
+		From:    []string{"/docs"},
+		To:      repo,
+		Options: entity.Options{"tag": []any{"daily"}},
+	}
+
+	queries, ok := entity.SnapshotQueries(job)
+	require.True(t, ok)
+	assert.Equal(t, []entity.SnapshotQuery{{
+		Repo:   repo,
+		Job:    "docs",
+		Filter: entity.SnapshotFilter{Paths: []string{"/docs"}, Tags: []string{"daily"}},
+	}}, queries)
+
+	_, ok = entity.SnapshotQueries(entity.CheckJob{Name: "check"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,186 @@
+// Package fssnapshot creates read-only Btrfs, ZFS and LVM snapshots
+// that backups read their sources from.
+package fssnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+type runner interface {
+	Run(ctx context.Context, service string, args ...string) *shell.Result
+}
+
+// Manager creates and removes filesystem snapshots.
+type Manager struct {
+	runner runner
+}
+
+func NewManager(runner runner) *Manager {
+	return &Manager{runner: runner}
+}
+
+// Snapshot is a created filesystem snapshot whose contents are available at Root.
+type Snapshot struct {
+	Root       string // Directory the snapshot contents are available at
+	mountpoint string
+	undo       []func(ctx context.Context) error
+}
+
+// Path returns the location of an original path inside the snapshot.
+// p must be inside the mountpoint of the snapshotted filesystem.
+func (s *Snapshot) Path(p string) string {
+	rel, err := filepath.Rel(s.mountpoint, p)
+	if err != nil {
+		return p
+	}
+	return filepath.Join(s.Root, rel)
+}
+
+// Create creates a snapshot named after name.
+// If a step fails, everything created so far is removed again.
+func (m *Manager) Create(ctx context.Context, cfg entity.FilesystemSnapshot, name string) (*Snapshot, error) {
+	ctx = logger.WithSource(ctx, "fs-snapshot")
+	log := logger.FromContext(ctx)
+	log.Info().Str("type", cfg.Type).Str("source", cfg.Source).Msg("Creating filesystem snapshot")
+
+	name = "crestic-" + sanitize(name)
+	s := &Snapshot{mountpoint: cfg.Mountpoint}
+
+	var err error
+	switch cfg.Type {
+	case entity.SnapshotBtrfs:
+		err = m.createBtrfs(ctx, s, cfg, name)
+	case entity.SnapshotZFS:
+		err = m.createZFS(ctx, s, cfg, name)
+	case entity.SnapshotLVM:
+		err = m.createLVM(ctx, s, cfg, name)
+	default:
+		err = fmt.Errorf("unknown filesystem snapshot type %q", cfg.Type)
+	}
+
+	if err != nil {
+		// The failure may come from ctx being canceled, the cleanup still has to run.
+		rErr := m.Remove(context.WithoutCancel(ctx), s)
+		return nil, errors.Join(err, rErr)
+	}
+
+	return s, nil
+}
+
+// Remove deletes the snapshot in the reverse order of its creation.
+// All steps are attempted even if some of them fail.
+func (m *Manager) Remove(ctx context.Context, s *Snapshot) error {
+	ctx = logger.WithSource(ctx, "fs-snapshot")
+	if len(s.undo) > 0 {
+		log := logger.FromContext(ctx)
+		log.Info().Str("root", s.Root).Msg("Removing filesystem snapshot")
+	}
+
+	var errs []error
+	for _, undo := range slices.Backward(s.undo) {
+		errs = append(errs, undo(ctx))
+	}
+	s.undo = nil
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) createBtrfs(ctx context.Context, s *Snapshot, cfg entity.FilesystemSnapshot, name string) error {
+	dst := filepath.Join(cfg.Source, "."+name)
+	err := m.run(ctx, "btrfs", "subvolume", "snapshot", "-r", cfg.Source, dst)
+	if err != nil {
+		return err
+	}
+
+	s.Root = dst
+	s.undo = append(s.undo, func(ctx context.Context) error {
+		return m.run(ctx, "btrfs", "subvolume", "delete", dst)
+	})
+	return nil
+}
+
+func (m *Manager) createZFS(ctx context.Context, s *Snapshot, cfg entity.FilesystemSnapshot, name string) error {
+	snapshot := cfg.Source + "@" + name
+	err := m.run(ctx, "zfs", "snapshot", snapshot)
+	if err != nil {
+		return err
+	}
+
+	s.Root = filepath.Join(cfg.Mountpoint, ".zfs", "snapshot", name)
+	s.undo = append(s.undo, func(ctx context.Context) error {
+		return m.run(ctx, "zfs", "destroy", snapshot)
+	})
+	return nil
+}
+
+func (m *Manager) createLVM(ctx context.Context, s *Snapshot, cfg entity.FilesystemSnapshot, name string) error {
+	vg, lv := path.Split(cfg.Source)
+	snapshot := lv + "-" + name
+	err := m.run(ctx, "lvcreate", "--snapshot", "--size", cfg.Size, "--name", snapshot, cfg.Source)
+	if err != nil {
+		return err
+	}
+
+	s.undo = append(s.undo, func(ctx context.Context) error {
+		return m.run(ctx, "lvremove", "--force", vg+snapshot)
+	})
+
+	dir, err := os.MkdirTemp("", name+"-")
+	if err != nil {
+		return fmt.Errorf("create mount directory: %w", err)
+	}
+
+	s.undo = append(s.undo, func(_ context.Context) error {
+		return os.Remove(dir)
+	})
+
+	device := "/dev/" + vg + snapshot
+	err = m.run(ctx, "mount", "-o", m.mountOptions(ctx, device), device, dir)
+	if err != nil {
+		return err
+	}
+
+	s.Root = dir
+	s.undo = append(s.undo, func(ctx context.Context) error {
+		return m.run(ctx, "umount", dir)
+	})
+	return nil
+}
+
+// mountOptions returns the options to mount a snapshot device read-only.
+// A snapshot of a mounted XFS filesystem has the same UUID as the original,
+// which XFS refuses to mount twice unless nouuid is set.
+func (m *Manager) mountOptions(ctx context.Context, device string) string {
+	result := m.runner.Run(ctx, "blkid", "-o", "value", "-s", "TYPE", device)
+	if result.Error == nil && strings.TrimSpace(result.Stdout) == "xfs" {
+		return "ro,nouuid"
+	}
+	return "ro"
+}
+
+func (m *Manager) run(ctx context.Context, service string, args ...string) error {
+	result := m.runner.Run(ctx, service, args...)
+	if result.Error != nil {
+		return fmt.Errorf("%s %s [exit code %d]: %w", service, strings.Join(args, " "), result.ExitCode, result.Error)
+	}
+	return nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// sanitize makes name usable in ZFS, LVM and file names.
+func sanitize(name string) string {
+	return unsafeChars.ReplaceAllString(name, "_")
+}
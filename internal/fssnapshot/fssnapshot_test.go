@@ -0,0 +1,167 @@
+package fssnapshot_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/fssnapshot"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRunner records commands and fails those starting with failOn, calling cancel if set.
+// Like the real executor, it fails every command run with a canceled context.
+// blkid reports fsType as the filesystem type.
+type fakeRunner struct {
+	calls  []string
+	failOn string
+	cancel context.CancelFunc
+	fsType string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, service string, args ...string) *shell.Result {
+	call := service + " " + strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+
+	if ctx.Err() != nil {
+		return &shell.Result{ExitCode: -1, Error: ctx.Err()}
+	}
+	if f.failOn != "" && strings.HasPrefix(call, f.failOn) {
+		if f.cancel != nil {
+			f.cancel()
+		}
+		return &shell.Result{ExitCode: 1, Error: errors.New("failed")}
+	}
+	if service == "blkid" {
+		return &shell.Result{Stdout: f.fsType + "\n"}
+	}
+	return &shell.Result{}
+}
+
+func TestBtrfs(t *testing.T) {
+	runner := &fakeRunner{}
+	m := fssnapshot.NewManager(runner)
+
+	s, err := m.Create(context.Background(), entity.FilesystemSnapshot{
+		Type:       entity.SnapshotBtrfs,
+		Source:     "/home",
+		Mountpoint: "/home",
+	}, "my docs")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/home/.crestic-my_docs/user/Documents", s.Path("/home/user/Documents"))
+	require.NoError(t, m.Remove(context.Background(), s))
+
+	assert.Equal(t, []string{
+		"btrfs subvolume snapshot -r /home /home/.crestic-my_docs",
+		"btrfs subvolume delete /home/.crestic-my_docs",
+	}, runner.calls)
+}
+
+func TestZFS(t *testing.T) {
+	runner := &fakeRunner{}
+	m := fssnapshot.NewManager(runner)
+
+	s, err := m.Create(context.Background(), entity.FilesystemSnapshot{
+		Type:       entity.SnapshotZFS,
+		Source:     "tank/home",
+		Mountpoint: "/home",
+	}, "docs")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/home/.zfs/snapshot/crestic-docs/user", s.Path("/home/user"))
+	require.NoError(t, m.Remove(context.Background(), s))
+
+	assert.Equal(t, []string{
+		"zfs snapshot tank/home@crestic-docs",
+		"zfs destroy tank/home@crestic-docs",
+	}, runner.calls)
+}
+
+func TestLVMCleansUpAfterFailedMount(t *testing.T) {
+	runner := &fakeRunner{failOn: "mount"}
+	m := fssnapshot.NewManager(runner)
+
+	_, err := m.Create(context.Background(), entity.FilesystemSnapshot{
+		Type:       entity.SnapshotLVM,
+		Source:     "vg0/home",
+		Mountpoint: "/home",
+		Size:       "1G",
+	}, "docs")
+	require.ErrorContains(t, err, "mount -o ro /dev/vg0/home-crestic-docs")
+
+	require.Len(t, runner.calls, 4)
+	assert.Equal(t, "lvcreate --snapshot --size 1G --name home-crestic-docs vg0/home", runner.calls[0])
+	assert.Equal(t, "lvremove --force vg0/home-crestic-docs", runner.calls[3])
+}
+
+func TestLVMMountsXFSWithoutUUIDCheck(t *testing.T) {
+	tests := []struct {
+		fsType   string
+		expected string
+	}{
+		{fsType: "xfs", expected: "ro,nouuid"},
+		{fsType: "ext4", expected: "ro"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fsType, func(t *testing.T) {
+			runner := &fakeRunner{fsType: tt.fsType}
+			m := fssnapshot.NewManager(runner)
+
+			s, err := m.Create(context.Background(), entity.FilesystemSnapshot{
+				Type:       entity.SnapshotLVM,
+				Source:     "vg0/home",
+				Mountpoint: "/home",
+				Size:       "1G",
+			}, "docs")
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = m.Remove(context.Background(), s) })
+
+			assert.Equal(t, "blkid -o value -s TYPE /dev/vg0/home-crestic-docs", runner.calls[1])
+			assert.Equal(t, "mount -o "+tt.expected+" /dev/vg0/home-crestic-docs "+s.Root, runner.calls[2])
+		})
+	}
+}
+
+func TestCleanupRunsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := &fakeRunner{failOn: "mount", cancel: cancel}
+	m := fssnapshot.NewManager(runner)
+
+	_, err := m.Create(ctx, entity.FilesystemSnapshot{
+		Type:       entity.SnapshotLVM,
+		Source:     "vg0/home",
+		Mountpoint: "/home",
+		Size:       "1G",
+	}, "docs")
+	require.ErrorContains(t, err, "mount -o ro")
+	assert.NotContains(t, err.Error(), "lvremove", "the snapshot is removed despite the canceled context")
+
+	require.Len(t, runner.calls, 4)
+	assert.Equal(t, "lvremove --force vg0/home-crestic-docs", runner.calls[3])
+}
+
+func TestRemoveContinuesAfterFailure(t *testing.T) {
+	runner := &fakeRunner{}
+	m := fssnapshot.NewManager(runner)
+
+	s, err := m.Create(context.Background(), entity.FilesystemSnapshot{
+		Type:       entity.SnapshotLVM,
+		Source:     "vg0/home",
+		Mountpoint: "/home",
+		Size:       "1G",
+	}, "docs")
+	require.NoError(t, err)
+
+	runner.failOn = "umount"
+	require.ErrorContains(t, m.Remove(context.Background(), s), "umount")
+	assert.Equal(t, "lvremove --force vg0/home-crestic-docs", runner.calls[len(runner.calls)-1])
+	assert.NoDirExists(t, s.Root)
+}
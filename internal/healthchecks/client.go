@@ -25,24 +25,78 @@ func NewJobsList(jobs []string) *JobsList {
 	return &JobsList{Jobs: jobs}
 }
 
+const (
+	defaultTimeout = 3 * time.Second
+	defaultRetries = 3
+	defaultBackoff = time.Second
+
+	// DefaultPingURL is the ping endpoint of the hosted Healthchecks.io service.
+	DefaultPingURL = "https://hc-ping.com"
+)
+
 type Client struct {
 	http    *http.Client
 	baseURL string
+	retries int
+	backoff time.Duration
 }
 
-func NewClient(baseURL string) (*Client, error) {
+// NewClient creates a Healthchecks client for the given ping URL.
+// Zero-valued options fall back to defaults: 3s timeout, 3 attempts, 1s initial backoff.
+func NewClient(baseURL string, opts entity.HealthcheckOptions) (*Client, error) {
 	baseURL = strings.TrimSpace(baseURL)
 	if baseURL == "" {
 		return nil, errors.New("empty base URL")
 	}
 
-	const timeout = 3 * time.Second
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	retries := opts.Retries
+	if retries <= 0 {
+		retries = defaultRetries
+	}
+
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
 	return &Client{
 		http:    &http.Client{Timeout: timeout},
 		baseURL: baseURL,
+		retries: retries,
+		backoff: backoff,
 	}, nil
 }
 
+// SlugURL builds a ping URL from a project ping key and a check slug:
+// {pingURL}/{pingKey}/{slug}. An empty pingURL means hosted Healthchecks.io.
+// Self-hosted instances usually serve pings under /ping, e.g. https://hc.example.com/ping.
+func SlugURL(pingURL, pingKey, slug string) (string, error) {
+	if pingKey == "" || slug == "" {
+		return "", errors.New("both ping_key and slug must be specified")
+	}
+
+	if pingURL == "" {
+		pingURL = DefaultPingURL
+	}
+
+	u, err := neturl.Parse(pingURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ping URL: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid ping URL %q: scheme and host are required", pingURL)
+	}
+
+	u.Path = path.Join("/", u.Path, pingKey, slug)
+	return u.String(), nil
+}
+
 // Start signals the beginning of task execution.
 // Enables tracking of "hanging" tasks that started but never completed.
 // baseURL should be the full healthcheck URL (e.g., https://hc-ping.com/{uuid} or https://hc-ping.com/{uuid}/{slug})
@@ -83,7 +137,7 @@ func (c *Client) post(ctx context.Context, endpoint, rid string, p any) error {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 
-	err = withRetry(ctx, func() error {
+	err = withRetry(ctx, c.retries, c.backoff, func() error {
 		return c.doPost(ctx, u, body)
 	})
 	if err != nil {
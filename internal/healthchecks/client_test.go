@@ -0,0 +1,233 @@
+package healthchecks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/healthchecks"
+)
+
+type ping struct {
+	Path string
+	RID  string
+	Body string
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	pings  []ping
+	status func(attempt int) int
+	calls  atomic.Int32
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	attempt := int(r.calls.Add(1))
+
+	r.mu.Lock()
+	r.pings = append(r.pings, ping{Path: req.URL.Path, RID: req.URL.Query().Get("rid"), Body: string(body)})
+	r.mu.Unlock()
+
+	status := http.StatusOK
+	if r.status != nil {
+		status = r.status(attempt)
+	}
+	w.WriteHeader(status)
+}
+
+func newServer(t *testing.T, rec *recorder) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fastOptions() entity.HealthcheckOptions {
+	return entity.HealthcheckOptions{
+		Timeout: time.Second,
+		Retries: 3,
+		Backoff: time.Millisecond,
+	}
+}
+
+func TestClientEndpoints(t *testing.T) {
+	rec := &recorder{}
+	srv := newServer(t, rec)
+
+	c, err := healthchecks.NewClient(srv.URL+"/ping/uuid", fastOptions())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	results := entity.NewJobResults()
+
+	require.NoError(t, c.Start(ctx, "rid-1", healthchecks.NewJobsList([]string{"docs"})))
+	require.NoError(t, c.Success(ctx, "rid-1", results))
+	require.NoError(t, c.Fail(ctx, "rid-1", results))
+
+	paths := make([]string, 0, len(rec.pings))
+	for _, p := range rec.pings {
+		assert.Equal(t, "rid-1", p.RID)
+		paths = append(paths, p.Path)
+	}
+
+	assert.Equal(t, []string{
+		"/ping/uuid/start",
+		"/ping/uuid",
+		"/ping/uuid/fail",
+	}, paths)
+	assert.JSONEq(t, `{"jobs":["docs"]}`, rec.pings[0].Body)
+}
+
+func TestClientRetriesServerErrors(t *testing.T) {
+	rec := &recorder{status: func(attempt int) int {
+		if attempt < 3 {
+			return http.StatusBadGateway
+		}
+		return http.StatusOK
+	}}
+	srv := newServer(t, rec)
+
+	c, err := healthchecks.NewClient(srv.URL, fastOptions())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Start(context.Background(), "", healthchecks.NewJobsList(nil)))
+	assert.Equal(t, int32(3), rec.calls.Load())
+}
+
+func TestClientGivesUpAfterConfiguredRetries(t *testing.T) {
+	rec := &recorder{status: func(int) int { return http.StatusServiceUnavailable }}
+	srv := newServer(t, rec)
+
+	opts := fastOptions()
+	opts.Retries = 5
+	c, err := healthchecks.NewClient(srv.URL, opts)
+	require.NoError(t, err)
+
+	require.Error(t, c.Start(context.Background(), "", healthchecks.NewJobsList(nil)))
+	assert.Equal(t, int32(5), rec.calls.Load())
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	rec := &recorder{status: func(int) int { return http.StatusNotFound }}
+	srv := newServer(t, rec)
+
+	c, err := healthchecks.NewClient(srv.URL, fastOptions())
+	require.NoError(t, err)
+
+	require.Error(t, c.Start(context.Background(), "", healthchecks.NewJobsList(nil)))
+	assert.Equal(t, int32(1), rec.calls.Load())
+}
+
+func TestClientBackoffRespectsContext(t *testing.T) {
+	rec := &recorder{status: func(int) int { return http.StatusInternalServerError }}
+	srv := newServer(t, rec)
+
+	opts := fastOptions()
+	opts.Backoff = time.Hour
+	c, err := healthchecks.NewClient(srv.URL, opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = c.Start(ctx, "", healthchecks.NewJobsList(nil))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int32(1), rec.calls.Load())
+}
+
+func TestClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	opts := fastOptions()
+	opts.Timeout = 20 * time.Millisecond
+	opts.Retries = 1
+	c, err := healthchecks.NewClient(srv.URL, opts)
+	require.NoError(t, err)
+
+	require.Error(t, c.Start(context.Background(), "", healthchecks.NewJobsList(nil)))
+}
+
+func TestSlugURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		pingURL  string
+		pingKey  string
+		slug     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "hosted service by default",
+			pingKey:  "key",
+			slug:     "crestic",
+			expected: "https://hc-ping.com/key/crestic",
+		},
+		{
+			name:     "self-hosted instance",
+			pingURL:  "https://hc.example.com/ping/",
+			pingKey:  "key",
+			slug:     "nightly",
+			expected: "https://hc.example.com/ping/key/nightly",
+		},
+		{
+			name:    "missing slug",
+			pingKey: "key",
+			wantErr: true,
+		},
+		{
+			name:    "relative ping URL",
+			pingURL: "hc.example.com/ping",
+			pingKey: "key",
+			slug:    "nightly",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := healthchecks.SlugURL(tt.pingURL, tt.pingKey, tt.slug)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, u)
+		})
+	}
+}
+
+func TestSlugURLPingsSelfHostedServer(t *testing.T) {
+	rec := &recorder{}
+	srv := newServer(t, rec)
+
+	u, err := healthchecks.SlugURL(srv.URL+"/ping", "ping-key", "db-backup")
+	require.NoError(t, err)
+
+	c, err := healthchecks.NewClient(u, fastOptions())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Success(context.Background(), "rid", entity.NewJobResults()))
+	require.Len(t, rec.pings, 1)
+	assert.Equal(t, "/ping/ping-key/db-backup", rec.pings[0].Path)
+}
@@ -21,21 +21,26 @@ func (e *retryableError) Unwrap() error {
 	return e.err
 }
 
-func withRetry(ctx context.Context, fn func() error) error {
+// withRetry calls fn up to attempts times while it returns a retryableError.
+// The delay between attempts starts at backoff and doubles after each retry.
+// Waiting is interrupted as soon as ctx is done.
+func withRetry(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
 	var lastErr error
-	maxRetries := 3
 
-	for attempt := range maxRetries {
+	for attempt := range attempts {
 		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s
-			backoff := backoffDuration(attempt)
+			delay := backoffDuration(backoff, attempt)
 			log := logger.FromContext(ctx)
 			log.Warn().
 				Int("attempt", attempt).
-				Int("max_retries", maxRetries).
-				Dur("backoff", backoff).
+				Int("max_retries", attempts).
+				Dur("backoff", delay).
 				Msg("Retrying healthcheck request")
-			time.Sleep(backoff)
+
+			err := sleep(ctx, delay)
+			if err != nil {
+				return fmt.Errorf("healthcheck retry interrupted: %w", errors.Join(err, lastErr))
+			}
 		}
 
 		err := fn()
@@ -54,20 +59,39 @@ func withRetry(ctx context.Context, fn func() error) error {
 	log := logger.FromContext(ctx)
 	log.Error().
 		Err(lastErr).
-		Int("attempts", maxRetries).
+		Int("attempts", attempts).
 		Msg("Healthcheck failed after all retries")
 
-	return fmt.Errorf("healthcheck failed after %d retries: %w", maxRetries, lastErr)
+	return fmt.Errorf("healthcheck failed after %d retries: %w", attempts, lastErr)
+}
+
+// sleep waits for d or until ctx is done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
-// backoffDuration returns 2^(attempt-1) seconds, capped to avoid overflow.
-//
-//nolint:gosec // overflow is prevented by capping shift below 63
-func backoffDuration(attempt int) time.Duration {
+// backoffDuration returns base * 2^(attempt-1), capped to avoid overflow.
+func backoffDuration(base time.Duration, attempt int) time.Duration {
 	if attempt < 1 {
 		attempt = 1
 	}
-	const maxAttempt = 62 // 1<<63 would overflow Duration
-	shift := min(attempt-1, maxAttempt)
-	return time.Second * time.Duration(1<<uint(shift))
+
+	const maxBackoff = time.Hour
+	d := base
+	for range attempt - 1 {
+		if d >= maxBackoff/2 {
+			return maxBackoff
+		}
+		d *= 2
+	}
+
+	return d
 }
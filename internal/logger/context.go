@@ -15,12 +15,21 @@ func FromContext(ctx context.Context) zerolog.Logger {
 
 // WithBackupJobFields adds job-related fields to context for backup job.
 func WithBackupJobFields(ctx context.Context, job entity.BackupJob) context.Context {
-	return FromContext(ctx).With().
+	l := FromContext(ctx).With().
 		Str("job", job.Name).
 		Str("repo", job.To.Name).
-		Str("repo_path", job.To.Path).
-		Strs("backup_sources", job.From).
-		Logger().WithContext(ctx)
+		Str("repo_path", job.To.Path)
+
+	switch {
+	case job.FromCommand != nil:
+		l = l.Str("stdin_filename", job.FromCommand.Filename)
+	case len(job.Dumps) > 0:
+		l = l.Int("databases", len(job.Dumps))
+	default:
+		l = l.Strs("backup_sources", job.From)
+	}
+
+	return l.Logger().WithContext(ctx)
 }
 
 // WithCopyJobFields adds job-related fields to context for copy job.
@@ -1,10 +1,12 @@
 package logger
 
 import (
-	"os"
+	"io"
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/alexander-kolodka/crestic/internal/redact"
 )
 
 type Format string
@@ -15,10 +17,11 @@ const (
 	FormatJSON  Format = "json"  // JSON output
 )
 
-// New creates a new logger with the specified format.
-func New(format Format, level zerolog.Level) zerolog.Logger {
+// New creates a new logger with the specified format writing to out.
+// Secrets are masked in everything it writes.
+func New(out io.Writer, format Format, level zerolog.Level) zerolog.Logger {
 	if format == FormatJSON {
-		return zerolog.New(os.Stdout).
+		return zerolog.New(redact.Writer(out)).
 			Level(level).
 			With().
 			Timestamp().
@@ -26,7 +29,7 @@ func New(format Format, level zerolog.Level) zerolog.Logger {
 	}
 
 	output := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
+		Out:        redact.Writer(out),
 		TimeFormat: time.RFC3339,
 		NoColor:    !hasColor(format),
 	}
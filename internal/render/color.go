@@ -0,0 +1,32 @@
+package render
+
+// Color is an ANSI foreground color.
+type Color string
+
+// All codes have the same length, so tables stay aligned when a column is colored in every row.
+const (
+	Default Color = "\x1b[39m"
+	Red     Color = "\x1b[31m"
+	Green   Color = "\x1b[32m"
+	Yellow  Color = "\x1b[33m"
+
+	reset = "\x1b[0m"
+)
+
+// Painter colors text when enabled and returns it unchanged otherwise.
+type Painter struct {
+	enabled bool
+}
+
+// NewPainter creates a Painter. Colors should be disabled for CI and non-terminal output.
+func NewPainter(enabled bool) Painter {
+	return Painter{enabled: enabled}
+}
+
+// Paint wraps s in the given color.
+func (p Painter) Paint(c Color, s string) string {
+	if !p.enabled {
+		return s
+	}
+	return string(c) + s + reset
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// JSON writes v as indented JSON.
+func JSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+
+	return nil
+}
+
+// Table writes aligned columns separated by two spaces.
+type Table struct {
+	tw *tabwriter.Writer
+}
+
+// NewTable creates a Table and writes the header row.
+func NewTable(w io.Writer, header ...string) *Table {
+	const padding = 2
+	t := &Table{tw: tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)}
+	t.Row(header...)
+	return t
+}
+
+// Row writes a single row.
+func (t *Table) Row(cols ...string) {
+	_, _ = fmt.Fprintln(t.tw, strings.Join(cols, "\t"))
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (t *Table) Flush() error {
+	return t.tw.Flush()
+}
+
+// Bytes formats a size in IEC units, e.g. "1.5 GiB".
+func Bytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Time formats a timestamp in the local time zone; zero time is rendered as "-".
+func Time(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// Duration rounds d to a human-friendly precision.
+func Duration(d time.Duration) string {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
+
+// OrDash returns "-" for an empty string.
+func OrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,105 @@
+package restic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// Diff is the difference between two snapshots as reported by `restic diff --json`.
+type Diff struct {
+	SourceSnapshot string       `json:"source_snapshot"`
+	TargetSnapshot string       `json:"target_snapshot"`
+	ChangedFiles   int          `json:"changed_files"`
+	Added          DiffStat     `json:"added"`
+	Removed        DiffStat     `json:"removed"`
+	Changes        []DiffChange `json:"changes,omitempty"`
+}
+
+// DiffStat counts entries and data added to or removed from a snapshot.
+type DiffStat struct {
+	Files     int    `json:"files"`
+	Dirs      int    `json:"dirs"`
+	Others    int    `json:"others"`
+	DataBlobs int    `json:"data_blobs"`
+	TreeBlobs int    `json:"tree_blobs"`
+	Bytes     uint64 `json:"bytes"`
+}
+
+// DiffChange is a single changed path. Modifier is "+" (added), "-" (removed),
+// "M" (content modified), "T" (type changed), "U" (metadata changed) or "?" (bitrot).
+type DiffChange struct {
+	Path     string `json:"path"`
+	Modifier string `json:"modifier"`
+}
+
+// Diff compares two snapshots of a repository.
+func (r *Service) Diff(ctx context.Context, repo *entity.Repository, from, to string) (*Diff, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Str("from", from).Str("to", to).Msg("Comparing snapshots")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"diff", "--json"}, repoArgs...)
+	args = append(args, from, to)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "diff")
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := parseDiff(result.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: parse restic diff output: %w", repo.Name, err)
+	}
+
+	return diff, nil
+}
+
+func parseDiff(stdout string) (*Diff, error) {
+	diff := &Diff{}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(nil, maxJSONLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var msg struct {
+			MessageType string `json:"message_type"`
+		}
+		err := json.Unmarshal(line, &msg)
+		if err != nil {
+			continue
+		}
+
+		switch msg.MessageType {
+		case "change":
+			var c DiffChange
+			err = json.Unmarshal(line, &c)
+			if err != nil {
+				return nil, err
+			}
+			diff.Changes = append(diff.Changes, c)
+		case "statistics":
+			changes := diff.Changes
+			err = json.Unmarshal(line, diff)
+			if err != nil {
+				return nil, err
+			}
+			diff.Changes = changes
+		default:
+		}
+	}
+
+	return diff, scanner.Err()
+}
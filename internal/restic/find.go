@@ -0,0 +1,89 @@
+package restic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// FindResult lists the entries of a single snapshot matching a `restic find` pattern.
+type FindResult struct {
+	Snapshot string `json:"snapshot"`
+	Matches  []Node `json:"matches"`
+}
+
+// Find searches snapshots matching the filter for files and directories matching any of the patterns.
+func (r *Service) Find(
+	ctx context.Context,
+	repo *entity.Repository,
+	filter entity.SnapshotFilter,
+	patterns ...string,
+) ([]FindResult, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Strs("patterns", patterns).Msg("Searching snapshots")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"find", "--json"}, repoArgs...)
+	args = append(args, filter.ToArgs()...)
+	args = append(args, patterns...)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "find")
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := parseFind([]byte(result.Stdout))
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: parse restic find output: %w", repo.Name, err)
+	}
+
+	return results, nil
+}
+
+// parseFind accepts both a JSON array of results and a stream of result objects,
+// as the output format differs between restic versions.
+func parseFind(stdout []byte) ([]FindResult, error) {
+	var results []FindResult
+
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			var batch []FindResult
+			err = json.Unmarshal(raw, &batch)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, batch...)
+			continue
+		}
+
+		var res FindResult
+		err = json.Unmarshal(raw, &res)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+}
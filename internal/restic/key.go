@@ -0,0 +1,103 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// Key is a repository key as reported by `restic key list --json`.
+type Key struct {
+	ID       string `json:"id"`
+	Current  bool   `json:"current"` // The key used to open the repository
+	UserName string `json:"userName"`
+	HostName string `json:"hostName"`
+	Created  string `json:"created"`
+}
+
+// Keys returns the keys of a repository.
+func (r *Service) Keys(ctx context.Context, repo *entity.Repository) ([]Key, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Msg("Listing repository keys")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"key", "list", "--json"}, repoArgs...)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "key list")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []Key{}
+	err = json.Unmarshal([]byte(result.Stdout), &keys)
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: parse restic key list output: %w", repo.Name, err)
+	}
+
+	return keys, nil
+}
+
+// AddKey adds a key with the password printed by newPasswordCMD.
+// restic reads new passwords only from files, so the password is passed
+// through a temporary file readable by the current user only.
+func (r *Service) AddKey(ctx context.Context, repo *entity.Repository, newPasswordCMD string) error {
+	log := logger.FromContext(ctx)
+	log.Info().Msg("Adding repository key")
+
+	password, err := r.runPasswordCommand(ctx, repo, newPasswordCMD)
+	if err != nil {
+		return fmt.Errorf("new key: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "crestic-key-*")
+	if err != nil {
+		return fmt.Errorf("create new password file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(password)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("write new password file: %w", err)
+	}
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"key", "add"}, repoArgs...)
+	args = append(args, "--new-password-file", f.Name())
+
+	result := r.runner.Run(ctx, "restic", args...)
+	return r.toErr(ctx, result, repo, "key add")
+}
+
+// RemoveKey removes a key from a repository. restic refuses to remove the key used to open repo.
+func (r *Service) RemoveKey(ctx context.Context, repo *entity.Repository, id string) error {
+	log := logger.FromContext(ctx)
+	log.Info().Str("key", id).Msg("Removing repository key")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"key", "remove"}, repoArgs...)
+	args = append(args, id)
+
+	result := r.runner.Run(ctx, "restic", args...)
+	return r.toErr(ctx, result, repo, "key remove")
+}
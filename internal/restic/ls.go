@@ -0,0 +1,85 @@
+package restic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// Node is a file system entry of a snapshot as reported by `restic ls --json`.
+type Node struct {
+	Name  string    `json:"name"`
+	Type  string    `json:"type"` // file, dir, symlink, ...
+	Path  string    `json:"path"`
+	Size  uint64    `json:"size"`
+	Mode  uint32    `json:"mode"` // os.FileMode bits
+	UID   uint32    `json:"uid"`
+	GID   uint32    `json:"gid"`
+	MTime time.Time `json:"mtime"`
+}
+
+// lsMessage is a single line of `restic ls --json` output.
+// Older restic versions use struct_type, newer ones message_type.
+type lsMessage struct {
+	Node
+
+	StructType  string `json:"struct_type"`
+	MessageType string `json:"message_type"`
+}
+
+// Ls lists all entries of a snapshot.
+// If paths are given, only entries below them are listed.
+func (r *Service) Ls(
+	ctx context.Context,
+	repo *entity.Repository,
+	snapshot string,
+	paths ...string,
+) ([]Node, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Str("snapshot", snapshot).Msg("Listing snapshot files")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"ls", "--json"}, repoArgs...)
+	args = append(args, snapshot)
+	args = append(args, paths...)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "ls")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLs(result.Stdout), nil
+}
+
+func parseLs(stdout string) []Node {
+	var nodes []Node
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(nil, maxJSONLineSize)
+	for scanner.Scan() {
+		var msg lsMessage
+		err := json.Unmarshal(scanner.Bytes(), &msg)
+		if err != nil {
+			continue
+		}
+
+		if msg.StructType != "node" && msg.MessageType != "node" {
+			continue
+		}
+
+		nodes = append(nodes, msg.Node)
+	}
+
+	return nodes
+}
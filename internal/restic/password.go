@@ -0,0 +1,149 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/redact"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// passwordCache keeps the output of password commands for the lifetime of the process.
+type passwordCache struct {
+	mu        sync.Mutex
+	passwords map[string]string
+}
+
+// repoArgs returns the flags selecting repo and its password source.
+// Passwords that aren't passed as flags and the repository env are set in the environment
+// of the returned ctx, so they only reach the restic child process.
+func (r *Service) repoArgs(ctx context.Context, repo *entity.Repository) (context.Context, []string, error) {
+	return r.passwordArgs(ctx, repo, []string{"-r", repo.Path}, "--", "RESTIC_")
+}
+
+// fromRepoArgs is like repoArgs for the source repository of `restic copy`.
+func (r *Service) fromRepoArgs(ctx context.Context, repo *entity.Repository) (context.Context, []string, error) {
+	return r.passwordArgs(ctx, repo, []string{"--from-repo", repo.Path}, "--from-", "RESTIC_FROM_")
+}
+
+// checkEnvConflict returns an error if two repositories used by one restic command set the same
+// env key to different values. restic has no per-repository environment, so one of the repositories
+// would be accessed with the other's values, e.g. the backend credentials of the wrong account.
+func checkEnvConflict(to, from *entity.Repository) error {
+	for _, key := range slices.Sorted(maps.Keys(to.Env)) {
+		value, ok := from.Env[key]
+		if ok && value != to.Env[key] {
+			return fmt.Errorf(
+				"repositories %s and %s set env %s to different values, restic can't use both in one command",
+				to.Name,
+				from.Name,
+				key,
+			)
+		}
+	}
+	return nil
+}
+
+func (r *Service) passwordArgs(
+	ctx context.Context,
+	repo *entity.Repository,
+	args []string,
+	flagPrefix string,
+	envPrefix string,
+) (context.Context, []string, error) {
+	env := make(map[string]string, len(repo.Env))
+	for key, value := range repo.Env {
+		resolved, err := r.secrets.Resolve(ctx, value)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("repository %s: env %s: %w", repo.Name, key, err)
+		}
+		env[key] = resolved
+	}
+	ctx = shell.WithEnv(ctx, env)
+
+	switch {
+	case repo.Password != "":
+		password, err := r.secrets.Resolve(ctx, repo.Password)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("repository %s: password: %w", repo.Name, err)
+		}
+		return shell.WithEnv(ctx, map[string]string{envPrefix + "PASSWORD": password}), args, nil
+	case repo.PasswordFile != "":
+		_, err := os.Stat(repo.PasswordFile)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("repository %s: password file: %w", repo.Name, err)
+		}
+		return ctx, append(args, flagPrefix+"password-file", repo.PasswordFile), nil
+	case repo.PasswordEnv != "":
+		password, ok := os.LookupEnv(repo.PasswordEnv)
+		if !ok {
+			return ctx, nil, fmt.Errorf("repository %s: environment variable %s is not set", repo.Name, repo.PasswordEnv)
+		}
+		redact.AddSecrets(password)
+		return shell.WithEnv(ctx, map[string]string{envPrefix + "PASSWORD": password}), args, nil
+	case repo.CachePassword:
+		password, err := r.cachedPassword(ctx, repo)
+		if err != nil {
+			return ctx, nil, err
+		}
+		return shell.WithEnv(ctx, map[string]string{envPrefix + "PASSWORD": password}), args, nil
+	default:
+		return ctx, append(args, flagPrefix+"password-command", repo.PasswordCMD), nil
+	}
+}
+
+// cachedPassword runs the password command of repo once and returns its output
+// without the trailing newline, as restic does.
+func (r *Service) cachedPassword(ctx context.Context, repo *entity.Repository) (string, error) {
+	r.passwords.mu.Lock()
+	defer r.passwords.mu.Unlock()
+
+	password, ok := r.passwords.passwords[repo.PasswordCMD]
+	if ok {
+		return password, nil
+	}
+
+	password, err := r.runPasswordCommand(ctx, repo, repo.PasswordCMD)
+	if err != nil {
+		return "", err
+	}
+
+	if r.passwords.passwords == nil {
+		r.passwords.passwords = make(map[string]string)
+	}
+	r.passwords.passwords[repo.PasswordCMD] = password
+
+	return password, nil
+}
+
+// runPasswordCommand runs a password command and returns its output without the trailing newline.
+func (r *Service) runPasswordCommand(ctx context.Context, repo *entity.Repository, command string) (string, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Msg("Running password command")
+
+	ctx = logger.WithSource(ctx, "password")
+	result := r.shell.Run(shell.WithSilence(ctx), "sh", "-c", command)
+	if result.Error != nil {
+		return "", fmt.Errorf(
+			"repository %s: password command failed [exit code %d]: %w",
+			repo.Name,
+			result.ExitCode,
+			result.Error,
+		)
+	}
+
+	password := strings.TrimRight(result.Stdout, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("repository %s: password command returned an empty password", repo.Name)
+	}
+
+	redact.AddSecrets(password)
+	return password, nil
+}
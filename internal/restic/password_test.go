@@ -0,0 +1,173 @@
+package restic_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic puts a restic stand-in on PATH that records its password environment
+// and arguments, and returns the path of the record file.
+func fakeRestic(t *testing.T) string {
+	dir := t.TempDir()
+	record := filepath.Join(dir, "record")
+	script := "#!/bin/sh\n" +
+		`printf '%s|%s|%s\n' "$RESTIC_PASSWORD" "$RESTIC_FROM_PASSWORD" "$*" >> ` + shell.Quote(record) + "\n" +
+		"echo '[]'\n"
+
+	err := os.WriteFile(filepath.Join(dir, "restic"), []byte(script), 0o755)
+	require.NoError(t, err)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("RESTIC_PASSWORD", "")
+	t.Setenv("RESTIC_FROM_PASSWORD", "")
+
+	return record
+}
+
+func records(t *testing.T, path string) []string {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+func TestCachedPasswordCommand(t *testing.T) {
+	record := fakeRestic(t)
+	counter := filepath.Join(t.TempDir(), "counter")
+	repo := &entity.Repository{
+		Name:          "local",
+		Path:          "/repo",
+		PasswordCMD:   "echo run >> " + shell.Quote(counter) + "; echo s3cret",
+		CachePassword: true,
+	}
+	svc := restic.NewService(shell.NewExecutor())
+
+	for range 2 {
+		_, err := svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{
+		"s3cret||snapshots --json -r /repo",
+		"s3cret||snapshots --json -r /repo",
+	}, records(t, record))
+	assert.Equal(t, []string{"run"}, records(t, counter), "the password command runs once")
+	assert.Empty(t, os.Getenv("RESTIC_PASSWORD"), "the password is only set for restic")
+}
+
+func TestCachedPasswordCommandFails(t *testing.T) {
+	record := fakeRestic(t)
+	repo := &entity.Repository{
+		Name:          "local",
+		Path:          "/repo",
+		PasswordCMD:   "echo locked >&2; exit 3",
+		CachePassword: true,
+	}
+	svc := restic.NewService(shell.NewExecutor())
+
+	_, err := svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+
+	require.ErrorContains(t, err, "repository local: password command failed [exit code 3]")
+	assert.ErrorContains(t, err, "locked")
+	assert.Empty(t, records(t, record), "restic doesn't start")
+}
+
+func TestPasswordEnv(t *testing.T) {
+	record := fakeRestic(t)
+	repo := &entity.Repository{Name: "local", Path: "/repo", PasswordEnv: "CRESTIC_TEST_PASSWORD"}
+	svc := restic.NewService(shell.NewExecutor())
+
+	_, err := svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+	require.ErrorContains(t, err, "repository local: environment variable CRESTIC_TEST_PASSWORD is not set")
+
+	t.Setenv("CRESTIC_TEST_PASSWORD", "from-env")
+	_, err = svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"from-env||snapshots --json -r /repo"}, records(t, record))
+}
+
+func TestPasswordFile(t *testing.T) {
+	record := fakeRestic(t)
+	file := filepath.Join(t.TempDir(), "password")
+	repo := &entity.Repository{Name: "local", Path: "/repo", PasswordFile: file}
+	svc := restic.NewService(shell.NewExecutor())
+
+	_, err := svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+	require.ErrorContains(t, err, "repository local: password file")
+
+	require.NoError(t, os.WriteFile(file, []byte("secret\n"), 0o600))
+	_, err = svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"||snapshots --json -r /repo --password-file " + file}, records(t, record))
+}
+
+func TestCopyPasswordSources(t *testing.T) {
+	record := fakeRestic(t)
+	t.Setenv("CRESTIC_TEST_PASSWORD", "source")
+	job := entity.CopyJob{
+		From: &entity.Repository{Name: "nas", Path: "/nas", PasswordEnv: "CRESTIC_TEST_PASSWORD"},
+		To:   &entity.Repository{Name: "usb", Path: "/usb", PasswordCMD: "echo target"},
+	}
+	svc := restic.NewService(shell.NewExecutor())
+
+	err := svc.Copy(context.Background(), job)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"|source|copy -r /usb --password-command echo target --from-repo /nas",
+	}, records(t, record))
+}
+
+func TestPasswordSecretReference(t *testing.T) {
+	record := fakeRestic(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "password"), []byte("from-file\n"), 0o600))
+	repo := &entity.Repository{
+		Name:     "local",
+		Path:     "/repo",
+		Password: "secret://file" + filepath.Join(dir, "password"),
+		Env:      map[string]string{"RESTIC_FROM_PASSWORD": "plain"},
+	}
+	svc := restic.NewService(shell.NewExecutor())
+
+	_, err := svc.Snapshots(context.Background(), repo, entity.SnapshotFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"from-file|plain|snapshots --json -r /repo"}, records(t, record))
+}
+
+func TestCopyConflictingEnv(t *testing.T) {
+	record := fakeRestic(t)
+	job := entity.CopyJob{
+		From: &entity.Repository{
+			Name: "src",
+			Path: "s3:s3.amazonaws.com/src",
+			Env:  map[string]string{"AWS_ACCESS_KEY_ID": "SRCKEY", "AWS_DEFAULT_REGION": "eu-west-1"},
+		},
+		To: &entity.Repository{
+			Name: "dst",
+			Path: "s3:s3.amazonaws.com/dst",
+			Env:  map[string]string{"AWS_ACCESS_KEY_ID": "DSTKEY", "AWS_DEFAULT_REGION": "eu-west-1"},
+		},
+	}
+	svc := restic.NewService(shell.NewExecutor())
+
+	err := svc.Copy(context.Background(), job)
+	require.EqualError(t, err,
+		"repositories dst and src set env AWS_ACCESS_KEY_ID to different values, restic can't use both in one command")
+
+	assert.Empty(t, records(t, record), "restic is not run")
+}
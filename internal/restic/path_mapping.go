@@ -0,0 +1,48 @@
+package restic
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// PathMapping maps original source paths to the directories their contents are read from,
+// e.g. the same directories inside a filesystem snapshot.
+type PathMapping map[string]string
+
+type pathMapping struct{}
+
+// WithPathMapping makes restic read the original paths from the mapped directories.
+// restic runs in a private mount namespace where every mapped directory is bind-mounted
+// over its original path, so snapshots still record the original paths.
+func WithPathMapping(ctx context.Context, m PathMapping) context.Context {
+	return context.WithValue(ctx, pathMapping{}, m)
+}
+
+func getPathMapping(ctx context.Context) PathMapping {
+	m, ok := ctx.Value(pathMapping{}).(PathMapping)
+	if !ok {
+		return nil
+	}
+	return m
+}
+
+// wrap returns the command running service with the mapped paths bind-mounted.
+func (m PathMapping) wrap(service string, args []string) (string, []string) {
+	originals := lo.Keys(m)
+	slices.Sort(originals)
+
+	script := make([]string, 0, len(originals)+2)
+	script = append(script, "set -e")
+	for _, orig := range originals {
+		script = append(script, "mount --bind "+shell.Quote(m[orig])+" "+shell.Quote(orig))
+	}
+	script = append(script, `exec "$@"`)
+
+	wrapped := []string{"--mount", "--propagation", "private", "--", "sh", "-c", strings.Join(script, "; "), "sh", service}
+	return "unshare", append(wrapped, args...)
+}
@@ -0,0 +1,27 @@
+package restic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathMappingWrap(t *testing.T) {
+	m := PathMapping{
+		"/home/user/Projects":  "/home/.crestic-docs/user/Projects",
+		"/home/user/Documents": "/home/.crestic-docs/user/Documents",
+	}
+
+	service, args := m.wrap("restic", []string{"backup", "/home/user/Documents", "/home/user/Projects"})
+
+	assert.Equal(t, "unshare", service)
+	assert.Equal(t, []string{
+		"--mount", "--propagation", "private", "--",
+		"sh", "-c",
+		"set -e; " +
+			"mount --bind '/home/.crestic-docs/user/Documents' '/home/user/Documents'; " +
+			"mount --bind '/home/.crestic-docs/user/Projects' '/home/user/Projects'; " +
+			`exec "$@"`,
+		"sh", "restic", "backup", "/home/user/Documents", "/home/user/Projects",
+	}, args)
+}
@@ -0,0 +1,18 @@
+package restic
+
+import (
+	"context"
+)
+
+type quietErrors struct{}
+
+// WithQuietErrors logs failed restic commands at debug level. It is used by callers
+// that expect failures and report them in their own output.
+func WithQuietErrors(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietErrors{}, true)
+}
+
+func hasQuietErrors(ctx context.Context) bool {
+	quiet, ok := ctx.Value(quietErrors{}).(bool)
+	return ok && quiet
+}
@@ -0,0 +1,45 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// RepoConfig is the repository configuration as reported by `restic cat config`.
+type RepoConfig struct {
+	Version           int    `json:"version"`
+	ID                string `json:"id"`
+	ChunkerPolynomial string `json:"chunker_polynomial"`
+}
+
+// Config returns the configuration of a repository. It is read without locking the repository.
+func (r *Service) Config(ctx context.Context, repo *entity.Repository) (*RepoConfig, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Msg("Reading repository config")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"cat", "config", "--no-lock"}, repoArgs...)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "cat config")
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg RepoConfig
+	err = json.Unmarshal([]byte(result.Stdout), &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: parse restic cat config output: %w", repo.Name, err)
+	}
+
+	return &cfg, nil
+}
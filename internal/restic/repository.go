@@ -3,9 +3,14 @@ package restic
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 
 	"github.com/alexander-kolodka/crestic/internal/entity"
 	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/secret"
 	"github.com/alexander-kolodka/crestic/internal/shell"
 )
 
@@ -21,28 +26,53 @@ const (
 
 // Service provides high-level operations for interacting with restic repositories.
 type Service struct {
-	runner runner
+	runner    runner
+	shell     runner
+	secrets   *secret.Resolver
+	passwords passwordCache
 }
 
 func NewService(runner runner) *Service {
 	return &Service{
-		runner: &resticRunner{runner: runner},
+		runner:  &resticRunner{runner: runner},
+		shell:   runner,
+		secrets: secret.NewResolver(runner),
 	}
 }
 
-// Init initializes a new restic repository at the specified path.
+// Init initializes a new restic repository at the specified path with the repository's init options.
 // This must be called before the repository can be used for backups.
 func (r *Service) Init(ctx context.Context, repo *entity.Repository) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Initializing repository")
 
-	result := r.runner.Run(
-		ctx,
-		"restic",
-		"init",
-		"-r", repo.Path,
-		"--password-command", repo.PasswordCMD,
-	)
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"init"}, repoArgs...)
+	if repo.InitOptions.RepositoryVersion != "" {
+		args = append(args, "--repository-version", repo.InitOptions.RepositoryVersion)
+	}
+
+	src := repo.InitOptions.CopyChunkerParamsFrom
+	if src != nil {
+		err = checkEnvConflict(repo, src)
+		if err != nil {
+			return err
+		}
+
+		var fromArgs []string
+		ctx, fromArgs, err = r.fromRepoArgs(ctx, src)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--copy-chunker-params")
+		args = append(args, fromArgs...)
+	}
+
+	result := r.runner.Run(ctx, "restic", args...)
 
 	return r.toErr(ctx, result, repo, "init")
 }
@@ -52,13 +82,12 @@ func (r *Service) IsRepoInitialized(ctx context.Context, repo *entity.Repository
 	log := logger.FromContext(ctx)
 	log.Debug().Msg("Checking if repository is initialized")
 
-	result := r.runner.Run(
-		shell.WithSilence(ctx),
-		"restic",
-		"stats",
-		"-r", repo.Path,
-		"--password-command", repo.PasswordCMD,
-	)
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", append([]string{"stats"}, repoArgs...)...)
 
 	if result.ExitCode == resticStatsExitCodeRepoDoesNotExist {
 		return false, nil
@@ -71,47 +100,124 @@ func (r *Service) IsRepoInitialized(ctx context.Context, repo *entity.Repository
 	return false, r.toErr(ctx, result, repo, "stats")
 }
 
-// Backup creates a new backup snapshot from the specified source directories.
-// If the context contains a dry-run flag, no actual backup is performed.
-func (r *Service) Backup(ctx context.Context, b entity.BackupJob) error {
+// EnsureInitialized initializes the repository if it doesn't exist yet.
+// If the repository has auto_init disabled, a missing repository is an error instead,
+// e.g. so a backup to an unmounted disk doesn't create a new repository in the empty mountpoint.
+func (r *Service) EnsureInitialized(ctx context.Context, repo *entity.Repository) error {
+	isRepoInitialized, err := r.IsRepoInitialized(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	if isRepoInitialized {
+		return nil
+	}
+
+	if !repo.AutoInit {
+		return fmt.Errorf(
+			"repository %s is not initialized and auto_init is disabled, run 'crestic init --repo %s' to create it",
+			repo.Name,
+			repo.Name,
+		)
+	}
+
+	return r.Init(ctx, repo)
+}
+
+// Backup creates a new backup snapshot from the specified source directories,
+// or from the standard output of the job's source command.
+// If the context contains a dry-run flag, no actual backup is performed
+// and the source command isn't run.
+// The returned summary is nil if the backup failed.
+func (r *Service) Backup(ctx context.Context, b entity.BackupJob) (*BackupSummary, error) {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Starting backup")
 
-	args := []string{
-		"backup",
-		"-r", b.To.Path,
-		"--password-command", b.To.PasswordCMD,
+	ctx, repoArgs, err := r.repoArgs(ctx, b.To)
+	if err != nil {
+		return nil, err
 	}
 
-	if isDryRun(ctx) {
+	args := append([]string{"backup"}, repoArgs...)
+
+	if IsDryRun(ctx) {
 		args = append(args, "--dry-run")
 	}
 
 	args = append(args, b.Options.ToArgs()...)
-	args = append(args, b.From...)
+	if b.FromCommand != nil && IsDryRun(ctx) {
+		// restic would run the command even with --dry-run, e.g. dump a database.
+		log.Info().
+			Str("command", b.FromCommand.Command).
+			Str("filename", b.FromCommand.Filename).
+			Msg("DRY RUN: would run command")
+		return &BackupSummary{}, nil
+	}
+	if b.FromCommand != nil {
+		args = append(args,
+			"--stdin-filename", b.FromCommand.Filename,
+			"--stdin-from-command", "--", "sh", "-c", b.FromCommand.Command,
+		)
+	} else {
+		args = append(args, b.From...)
+	}
 
 	result := r.runner.Run(ctx, "restic", args...)
 
+	if b.FromCommand != nil && strings.Contains(result.Stderr, "unknown flag: --stdin-from-command") {
+		return nil, fmt.Errorf(
+			"repository %s: backing up from_command or database sources requires restic 0.17 or newer",
+			b.To.Name,
+		)
+	}
+
 	if b.IgnoreMissingXAttrsError && result.ExitCode == resticBackupExitCodeMissingXAttrs {
 		log.Warn().Msg("Backup failed with missing xattrs, but it was ignored")
-		return nil
+		return r.backupSummary(ctx, b.To, result.Stdout), nil
+	}
+
+	err = r.toErr(ctx, result, b.To, "backup")
+	if err != nil {
+		return nil, err
+	}
+
+	return r.backupSummary(ctx, b.To, result.Stdout), nil
+}
+
+// backupSummary parses restic backup output. Text output doesn't contain statistics,
+// so they are read from the created snapshot instead.
+func (r *Service) backupSummary(ctx context.Context, repo *entity.Repository, stdout string) *BackupSummary {
+	summary := backupSummary(stdout)
+	if summary.Stats != nil || summary.SnapshotID == "" {
+		return summary
 	}
 
-	return r.toErr(ctx, result, b.To, "backup")
+	snapshots, err := r.Snapshots(ctx, repo, entity.SnapshotFilter{}, summary.SnapshotID)
+	if err != nil || len(snapshots) == 0 {
+		log := logger.FromContext(ctx)
+		log.Debug().Err(err).Str("snapshot", summary.SnapshotID).Msg("Failed to read snapshot summary")
+		return summary
+	}
+
+	summary.Stats = snapshots[0].Summary
+	return summary
 }
 
 // Check verifies the integrity of a repository.
-func (r *Service) Check(ctx context.Context, repo *entity.Repository) error {
+// Additional check options (e.g. read-data-subset) may be passed in opts.
+func (r *Service) Check(ctx context.Context, repo *entity.Repository, opts entity.Options) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Running integrity check")
 
-	result := r.runner.Run(
-		ctx,
-		"restic",
-		"check",
-		"-r", repo.Path,
-		"--password-command", repo.PasswordCMD,
-	)
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"check"}, repoArgs...)
+	args = append(args, opts.ToArgs()...)
+
+	result := r.runner.Run(ctx, "restic", args...)
 
 	return r.toErr(ctx, result, repo, "check")
 }
@@ -120,42 +226,82 @@ func (r *Service) Check(ctx context.Context, repo *entity.Repository) error {
 // This only marks snapshots for deletion; use with --prune flag (in ForgetOptions)
 // to actually remove the data and free disk space.
 func (r *Service) Forget(ctx context.Context, repo *entity.Repository) error {
+	return r.ForgetWithOptions(ctx, repo, repo.ForgetOptions)
+}
+
+// ForgetWithOptions removes old snapshots according to the given retention policy
+// instead of the repository's forget_options.
+func (r *Service) ForgetWithOptions(ctx context.Context, repo *entity.Repository, opts entity.Options) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Running forget")
 
-	args := []string{
-		"forget",
-		"-r", repo.Path,
-		"--password-command", repo.PasswordCMD,
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
 	}
 
-	if isDryRun(ctx) {
+	args := append([]string{"forget"}, repoArgs...)
+
+	if IsDryRun(ctx) {
 		args = append(args, "--dry-run")
 	}
 
-	args = append(args, repo.ForgetOptions.ToArgs()...)
+	args = append(args, opts.ToArgs()...)
 
 	result := r.runner.Run(ctx, "restic", args...)
 
 	return r.toErr(ctx, result, repo, "forget")
 }
 
+// Prune removes data that is no longer referenced by any snapshot.
+// Additional prune options (e.g. max-unused) may be passed in opts.
+func (r *Service) Prune(ctx context.Context, repo *entity.Repository, opts entity.Options) error {
+	log := logger.FromContext(ctx)
+	log.Info().Msg("Running prune")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"prune"}, repoArgs...)
+
+	if IsDryRun(ctx) {
+		args = append(args, "--dry-run")
+	}
+
+	args = append(args, opts.ToArgs()...)
+
+	result := r.runner.Run(ctx, "restic", args...)
+
+	return r.toErr(ctx, result, repo, "prune")
+}
+
 // Copy copies snapshots from one repository to another.
 func (r *Service) Copy(ctx context.Context, job entity.CopyJob) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Starting copy")
 
-	args := []string{
-		"copy",
-		"-r", job.To.Path,
-		"--from-repo", job.From.Path,
-		"--password-command", job.To.PasswordCMD,
-		"--from-password-command", job.From.PasswordCMD,
+	err := checkEnvConflict(job.To, job.From)
+	if err != nil {
+		return err
+	}
+
+	ctx, toArgs, err := r.repoArgs(ctx, job.To)
+	if err != nil {
+		return err
 	}
 
+	ctx, fromArgs, err := r.fromRepoArgs(ctx, job.From)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"copy"}, toArgs...)
+	args = append(args, fromArgs...)
 	args = append(args, job.Options.ToArgs()...)
 
-	if isDryRun(ctx) {
+	if IsDryRun(ctx) {
 		log.Debug().
 			Strs("args", args).
 			Msg("DRY RUN: would execute restic copy")
@@ -168,6 +314,10 @@ func (r *Service) Copy(ctx context.Context, job entity.CopyJob) error {
 		return nil
 	}
 
+	if result.TimedOut {
+		return fmt.Errorf("repository %s: restic copy from %s %w", job.To.Name, job.From.Name, result.Error)
+	}
+
 	return fmt.Errorf(
 		"repository %s: restic copy from %s failed [exit code %d]: %w",
 		job.To.Name,
@@ -177,50 +327,87 @@ func (r *Service) Copy(ctx context.Context, job entity.CopyJob) error {
 	)
 }
 
+// RestoreOptions controls what is restored and how existing files are treated.
+type RestoreOptions struct {
+	Target    string   // Directory to restore to; "/" restores files to their original locations
+	Include   []string // Restore only paths matching these patterns
+	Exclude   []string // Skip paths matching these patterns
+	Overwrite string   // restic --overwrite mode: always, if-changed, if-newer or never
+}
+
 // Restore extracts files from a snapshot to the specified target directory.
 // The snapshot parameter can be a snapshot ID or "latest" for the most recent snapshot.
+// If the context contains a dry-run flag, restic only reports what would be restored.
 func (r *Service) Restore(
 	ctx context.Context,
 	repo *entity.Repository,
-	target, snapshot string,
+	snapshot string,
+	opts RestoreOptions,
 ) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Starting restore")
 
-	args := []string{
-		"restore",
-		"--target", target,
-		"-r", repo.Path,
-		"--password-command", repo.PasswordCMD,
-		snapshot,
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"restore", "--target", opts.Target}, repoArgs...)
+
+	if IsDryRun(ctx) {
+		args = append(args, "--dry-run")
+	}
+
+	if opts.Overwrite != "" {
+		args = append(args, "--overwrite", opts.Overwrite)
+	}
+
+	for _, p := range opts.Include {
+		args = append(args, "--include", p)
 	}
 
+	for _, p := range opts.Exclude {
+		args = append(args, "--exclude", p)
+	}
+
+	args = append(args, snapshot)
+
 	result := r.runner.Run(ctx, "restic", args...)
 	return r.toErr(ctx, result, repo, "restore")
 }
 
-// Exec executes an arbitrary restic command on a repository.
+// EscapePattern escapes glob metacharacters, so an --include or --exclude pattern matches the path literally.
+func EscapePattern(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if strings.ContainsRune(`\*?[`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Exec executes an arbitrary restic command on a repository and returns restic's exit code.
 func (r *Service) Exec(
 	ctx context.Context,
 	repo *entity.Repository,
 	cmd string,
 	args []string,
-) error {
+) (int, error) {
 	log := logger.FromContext(ctx)
 	log.Debug().Msg("Executing restic command")
 
-	args = append(
-		[]string{
-			cmd,
-			"-r", repo.Path,
-			"--password-command", repo.PasswordCMD,
-		},
-		args...,
-	)
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return 0, err
+	}
+
+	args = append(append([]string{cmd}, repoArgs...), args...)
 
 	result := r.runner.Run(ctx, "restic", args...)
 
-	return r.toErr(ctx, result, repo, cmd)
+	return result.ExitCode, r.toErr(ctx, result, repo, cmd)
 }
 
 // Unlock removes stale locks from a repository.
@@ -228,13 +415,12 @@ func (r *Service) Unlock(ctx context.Context, repo *entity.Repository) error {
 	log := logger.FromContext(ctx)
 	log.Info().Msg("Unlocking repository")
 
-	result := r.runner.Run(
-		ctx,
-		"restic",
-		"unlock",
-		"-r", repo.Path,
-		"--password-command", repo.PasswordCMD,
-	)
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	result := r.runner.Run(ctx, "restic", append([]string{"unlock"}, repoArgs...)...)
 
 	return r.toErr(ctx, result, repo, "unlock")
 }
@@ -245,11 +431,21 @@ func (r *Service) toErr(ctx context.Context, result *shell.Result, repo *entity.
 	}
 
 	log := logger.FromContext(ctx)
-	log.Error().
+	level := zerolog.ErrorLevel
+	if hasQuietErrors(ctx) {
+		level = zerolog.DebugLevel
+	}
+	log.WithLevel(level).
 		Str("cmd", cmdName).
 		Int("exit_code", result.ExitCode).
+		Bool("timed_out", result.TimedOut).
 		Err(result.Error).
-		Msg("restic command failed")
+		Msg(lo.Ternary(result.TimedOut, "restic command timed out", "restic command failed"))
+
+	if result.TimedOut {
+		// The error of a timed out command reads "timed out after ...".
+		return fmt.Errorf("repository %s: restic %s %w", repo.Name, cmdName, result.Error)
+	}
 
 	return fmt.Errorf(
 		"repository %s: restic %s failed [exit code %d]: %w",
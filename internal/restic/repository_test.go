@@ -0,0 +1,138 @@
+package restic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// missingRepoRunner reports every repository as not initialized and records restic calls.
+type missingRepoRunner struct {
+	calls [][]string
+}
+
+func (f *missingRepoRunner) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	f.calls = append(f.calls, args)
+	if args[0] == "stats" {
+		return &shell.Result{ExitCode: 10, Error: errors.New("repository does not exist")}
+	}
+	return &shell.Result{}
+}
+
+func TestInitOptions(t *testing.T) {
+	fake := &missingRepoRunner{}
+	src := &entity.Repository{Name: "nas", Path: "/nas", PasswordCMD: "echo nas"}
+	repo := &entity.Repository{
+		Name:        "usb",
+		Path:        "/usb",
+		PasswordCMD: "echo usb",
+		InitOptions: entity.InitOptions{
+			RepositoryVersion:     "2",
+			CopyChunkerParamsFrom: src,
+		},
+	}
+
+	err := restic.NewService(fake).Init(context.Background(), repo)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{
+		"init",
+		"-r", "/usb",
+		"--password-command", "echo usb",
+		"--repository-version", "2",
+		"--copy-chunker-params",
+		"--from-repo", "/nas",
+		"--from-password-command", "echo nas",
+	}}, fake.calls)
+}
+
+func TestEnsureInitialized(t *testing.T) {
+	fake := &missingRepoRunner{}
+	repo := &entity.Repository{Name: "usb", Path: "/usb", AutoInit: true}
+
+	err := restic.NewService(fake).EnsureInitialized(context.Background(), repo)
+	require.NoError(t, err)
+
+	require.Len(t, fake.calls, 2)
+	assert.Equal(t, "init", fake.calls[1][0])
+}
+
+func TestEnsureInitializedWithoutAutoInit(t *testing.T) {
+	fake := &missingRepoRunner{}
+	repo := &entity.Repository{Name: "usb", Path: "/usb", AutoInit: false}
+
+	err := restic.NewService(fake).EnsureInitialized(context.Background(), repo)
+	require.ErrorContains(t, err, "repository usb is not initialized and auto_init is disabled")
+
+	require.Len(t, fake.calls, 1, "the repository is not created")
+}
+
+func TestInitRejectsConflictingEnv(t *testing.T) {
+	fake := &missingRepoRunner{}
+	src := &entity.Repository{Name: "nas", Path: "s3:s3.amazonaws.com/nas", Env: map[string]string{"AWS_PROFILE": "nas"}}
+	repo := &entity.Repository{
+		Name:        "offsite",
+		Path:        "s3:s3.amazonaws.com/offsite",
+		Env:         map[string]string{"AWS_PROFILE": "offsite"},
+		InitOptions: entity.InitOptions{CopyChunkerParamsFrom: src},
+	}
+
+	err := restic.NewService(fake).Init(context.Background(), repo)
+	require.EqualError(t, err,
+		"repositories offsite and nas set env AWS_PROFILE to different values, restic can't use both in one command")
+
+	assert.Empty(t, fake.calls, "the repository is not created")
+}
+
+// timeoutRunner reports every restic command as timed out.
+type timeoutRunner struct{}
+
+func (timeoutRunner) Run(context.Context, string, ...string) *shell.Result {
+	return &shell.Result{ExitCode: -1, TimedOut: true, Error: errors.New("timed out after 1h0m0s: signal: killed")}
+}
+
+func TestTimedOutCommandError(t *testing.T) {
+	err := restic.NewService(timeoutRunner{}).Check(context.Background(), &entity.Repository{Name: "nas"}, nil)
+	require.EqualError(t, err, "repository nas: restic check timed out after 1h0m0s: signal: killed")
+}
+
+func TestBackupFromCommandDryRun(t *testing.T) {
+	fake := &missingRepoRunner{}
+	job := entity.BackupJob{
+		Name:        "db",
+		To:          &entity.Repository{Name: "nas", Path: "/nas"},
+		FromCommand: &entity.CommandSource{Command: "pg_dump app", Filename: "app.dump"},
+	}
+
+	summary, err := restic.NewService(fake).Backup(restic.WithDryRun(context.Background()), job)
+	require.NoError(t, err)
+
+	assert.Empty(t, summary.SnapshotID)
+	assert.Empty(t, fake.calls, "restic would run the command despite --dry-run")
+}
+
+type oldResticRunner struct{}
+
+func (oldResticRunner) Run(context.Context, string, ...string) *shell.Result {
+	stderr := "unknown flag: --stdin-from-command\n"
+	return &shell.Result{ExitCode: 1, Stderr: stderr, Error: errors.New("exit status 1: " + stderr)}
+}
+
+func TestBackupFromCommandRequiresRestic017(t *testing.T) {
+	job := entity.BackupJob{
+		Name:        "db",
+		To:          &entity.Repository{Name: "nas", Path: "/nas"},
+		FromCommand: &entity.CommandSource{Command: "pg_dump app", Filename: "app.dump"},
+	}
+
+	_, err := restic.NewService(oldResticRunner{}).Backup(context.Background(), job)
+	require.EqualError(t, err,
+		"repository nas: backing up from_command or database sources requires restic 0.17 or newer")
+}
@@ -26,5 +26,10 @@ func (r *resticRunner) Run(ctx context.Context, service string, args ...string)
 		args = newArgs
 	}
 
+	m := getPathMapping(ctx)
+	if len(m) > 0 {
+		service, args = m.wrap(service, args)
+	}
+
 	return r.runner.Run(ctx, service, args...)
 }
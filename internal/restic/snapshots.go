@@ -0,0 +1,145 @@
+package restic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// Snapshot is a restic snapshot as reported by `restic snapshots --json`.
+type Snapshot struct {
+	ID       string              `json:"id"`
+	ShortID  string              `json:"short_id"`
+	Time     time.Time           `json:"time"`
+	Hostname string              `json:"hostname"`
+	Username string              `json:"username"`
+	Paths    []string            `json:"paths"`
+	Tags     []string            `json:"tags,omitempty"`
+	Parent   string              `json:"parent,omitempty"`
+	Summary  *entity.BackupStats `json:"summary,omitempty"`
+}
+
+// BackupSummary describes the outcome of a restic backup run.
+type BackupSummary struct {
+	SnapshotID string              // ID of the created snapshot; empty if none was created
+	Skipped    bool                // True if restic skipped creating a snapshot (skip-if-unchanged)
+	Stats      *entity.BackupStats // Backup statistics; nil if restic did not report them
+}
+
+// maxJSONLineSize limits the length of a single line of restic JSON output.
+const maxJSONLineSize = 1024 * 1024
+
+var (
+	snapshotSavedRe   = regexp.MustCompile(`snapshot ([0-9a-f]{8,64}) saved`)
+	snapshotSkippedRe = regexp.MustCompile(`skipped creating snapshot`)
+)
+
+// Snapshots lists snapshots matching the filter, oldest first.
+// If ids are given, only those snapshots are listed ("latest" is accepted as well).
+func (r *Service) Snapshots(
+	ctx context.Context,
+	repo *entity.Repository,
+	filter entity.SnapshotFilter,
+	ids ...string,
+) ([]Snapshot, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Msg("Listing snapshots")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"snapshots", "--json"}, repoArgs...)
+	args = append(args, filter.ToArgs()...)
+	args = append(args, ids...)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "snapshots")
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	err = json.Unmarshal([]byte(result.Stdout), &snapshots)
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: parse restic snapshots output: %w", repo.Name, err)
+	}
+
+	return snapshots, nil
+}
+
+// backupSummary extracts the snapshot ID and statistics from restic backup output.
+// In JSON mode restic prints a summary message; in text mode only the snapshot ID is available.
+func backupSummary(stdout string) *BackupSummary {
+	summary := &BackupSummary{}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(nil, maxJSONLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "{") {
+			parseSummaryMessage(line, summary)
+			continue
+		}
+
+		m := snapshotSavedRe.FindStringSubmatch(line)
+		if m != nil {
+			summary.SnapshotID = m[1]
+			continue
+		}
+
+		if snapshotSkippedRe.MatchString(line) {
+			summary.Skipped = true
+		}
+	}
+
+	return summary
+}
+
+func parseSummaryMessage(line string, summary *BackupSummary) {
+	var msg struct {
+		MessageType string `json:"message_type"`
+		SnapshotID  string `json:"snapshot_id"`
+		DryRun      bool   `json:"dry_run"`
+		entity.BackupStats
+	}
+
+	err := json.Unmarshal([]byte(line), &msg)
+	if err != nil || msg.MessageType != "summary" {
+		return
+	}
+
+	summary.SnapshotID = msg.SnapshotID
+	summary.Skipped = msg.SnapshotID == "" && !msg.DryRun
+	summary.Stats = &msg.BackupStats
+}
+
+// Matches reports whether the snapshot satisfies the filter the same way restic does:
+// the host must match, and the snapshot must contain all filter paths and tags.
+func (s Snapshot) Matches(f entity.SnapshotFilter) bool {
+	if f.Host != "" && f.Host != s.Hostname {
+		return false
+	}
+
+	return containsAll(s.Paths, f.Paths) && containsAll(s.Tags, f.Tags)
+}
+
+func containsAll(set, items []string) bool {
+	for _, item := range items {
+		if !slices.Contains(set, item) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package restic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+func TestBackupSummaryText(t *testing.T) {
+	stdout := `open repository
+using parent snapshot 11aa22bb
+
+Files:           1 new,     0 changed,    12 unmodified
+Added to the repository: 1.234 KiB (893 B stored)
+
+processed 13 files, 1.021 MiB in 0:00
+snapshot 4f6c3a2b saved
+`
+
+	summary := backupSummary(stdout)
+	assert.Equal(t, "4f6c3a2b", summary.SnapshotID)
+	assert.False(t, summary.Skipped)
+	assert.Nil(t, summary.Stats)
+}
+
+func TestBackupSummaryTextSkipped(t *testing.T) {
+	summary := backupSummary("processed 13 files, 1.021 MiB in 0:00\nskipped creating snapshot\n")
+	assert.Empty(t, summary.SnapshotID)
+	assert.True(t, summary.Skipped)
+}
+
+func TestBackupSummaryJSON(t *testing.T) {
+	stdout := `{"message_type":"status","percent_done":1}
+{"message_type":"summary","files_new":2,"files_changed":1,"files_unmodified":10,` +
+		`"data_added":2048,"total_files_processed":13,"total_bytes_processed":1070596,` +
+		`"snapshot_id":"4f6c3a2b9d0e"}
+`
+
+	summary := backupSummary(stdout)
+	assert.Equal(t, "4f6c3a2b9d0e", summary.SnapshotID)
+	assert.False(t, summary.Skipped)
+	require.NotNil(t, summary.Stats)
+	assert.Equal(t, uint64(2), summary.Stats.FilesNew)
+	assert.Equal(t, uint64(2048), summary.Stats.DataAdded)
+	assert.Equal(t, uint64(1070596), summary.Stats.TotalBytesProcessed)
+}
+
+func TestBackupSummaryJSONSkipped(t *testing.T) {
+	summary := backupSummary(`{"message_type":"summary","files_unmodified":13,"total_files_processed":13}`)
+	assert.True(t, summary.Skipped)
+	assert.Empty(t, summary.SnapshotID)
+}
+
+func TestSnapshotMatches(t *testing.T) {
+	s := Snapshot{
+		Hostname: "nas",
+		Paths:    []string{"/home/user/Documents", "/home/user/Projects"},
+		Tags:     []string{"documents", "daily"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   entity.SnapshotFilter
+		expected bool
+	}{
+		{name: "empty filter", filter: entity.SnapshotFilter{}, expected: true},
+		{name: "same host", filter: entity.SnapshotFilter{Host: "nas"}, expected: true},
+		{name: "other host", filter: entity.SnapshotFilter{Host: "laptop"}, expected: false},
+		{
+			name:     "subset of paths",
+			filter:   entity.SnapshotFilter{Paths: []string{"/home/user/Documents"}},
+			expected: true,
+		},
+		{
+			name:     "missing path",
+			filter:   entity.SnapshotFilter{Paths: []string{"/home/user/Photos"}},
+			expected: false,
+		},
+		{
+			name:     "all tags present",
+			filter:   entity.SnapshotFilter{Tags: []string{"daily", "documents"}},
+			expected: true,
+		},
+		{
+			name:     "missing tag",
+			filter:   entity.SnapshotFilter{Tags: []string{"documents", "weekly"}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.Matches(tt.filter))
+		})
+	}
+}
+
+func TestParseFind(t *testing.T) {
+	match := `{"snapshot":"abc","matches":[{"path":"/data/a.txt","type":"file","size":3,"mtime":"2026-01-01T00:00:00Z"}]}`
+
+	for name, stdout := range map[string]string{
+		"array":  "[" + match + "," + match + "]",
+		"stream": match + "\n" + match + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			results, err := parseFind([]byte(stdout))
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, "abc", results[1].Snapshot)
+			assert.Equal(t, "/data/a.txt", results[1].Matches[0].Path)
+			assert.Equal(t, uint64(3), results[1].Matches[0].Size)
+		})
+	}
+}
+
+func TestParseDiff(t *testing.T) {
+	stdout := `{"message_type":"change","path":"/data/new.txt","modifier":"+"}
+{"message_type":"change","path":"/data/notes.md","modifier":"M"}
+{"message_type":"statistics","source_snapshot":"aaa","target_snapshot":"bbb","changed_files":1,` +
+		`"added":{"files":2,"dirs":0,"others":0,"data_blobs":3,"tree_blobs":1,"bytes":4096},` +
+		`"removed":{"files":1,"dirs":0,"others":0,"data_blobs":1,"tree_blobs":1,"bytes":1024}}
+`
+
+	diff, err := parseDiff(stdout)
+	require.NoError(t, err)
+
+	assert.Equal(t, "aaa", diff.SourceSnapshot)
+	assert.Equal(t, 1, diff.ChangedFiles)
+	assert.Equal(t, 2, diff.Added.Files)
+	assert.Equal(t, uint64(1024), diff.Removed.Bytes)
+	assert.Equal(t, []DiffChange{
+		{Path: "/data/new.txt", Modifier: "+"},
+		{Path: "/data/notes.md", Modifier: "M"},
+	}, diff.Changes)
+}
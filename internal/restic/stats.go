@@ -0,0 +1,106 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// staleLockAge matches restic's own rule: locks not refreshed for 30 minutes are stale.
+const staleLockAge = 30 * time.Minute
+
+// RepoStats is the raw storage usage of a repository as reported by `restic stats --mode raw-data`.
+type RepoStats struct {
+	TotalSize             uint64  `json:"total_size"`
+	TotalUncompressedSize uint64  `json:"total_uncompressed_size,omitempty"`
+	CompressionRatio      float64 `json:"compression_ratio,omitempty"`
+	TotalBlobCount        uint64  `json:"total_blob_count,omitempty"`
+	SnapshotsCount        int     `json:"snapshots_count"`
+}
+
+// Lock is a repository lock as reported by `restic cat lock`.
+type Lock struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username"`
+	PID       int       `json:"pid"`
+}
+
+// IsStale reports whether the lock hasn't been refreshed for longer than restic allows.
+func (l Lock) IsStale(now time.Time) bool {
+	return now.Sub(l.Time) > staleLockAge
+}
+
+// Stats returns the storage usage of a repository.
+func (r *Service) Stats(ctx context.Context, repo *entity.Repository) (*RepoStats, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Msg("Reading repository stats")
+
+	ctx, repoArgs, err := r.repoArgs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"stats", "--json", "--mode", "raw-data"}, repoArgs...)
+
+	result := r.runner.Run(shell.WithSilence(ctx), "restic", args...)
+	err = r.toErr(ctx, result, repo, "stats")
+	if err != nil {
+		return nil, err
+	}
+
+	var stats RepoStats
+	err = json.Unmarshal([]byte(result.Stdout), &stats)
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: parse restic stats output: %w", repo.Name, err)
+	}
+
+	return &stats, nil
+}
+
+// Locks returns all locks currently present in the repository.
+// Listing is done with --no-lock, so it doesn't create a lock itself.
+func (r *Service) Locks(ctx context.Context, repo *entity.Repository) ([]Lock, error) {
+	log := logger.FromContext(ctx)
+	log.Debug().Msg("Listing repository locks")
+
+	ctx, repoArgs, err := r.repoArgs(shell.WithSilence(ctx), repo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.runner.Run(ctx, "restic", append([]string{"list", "locks", "--no-lock"}, repoArgs...)...)
+	err = r.toErr(ctx, result, repo, "list locks")
+	if err != nil {
+		return nil, err
+	}
+
+	locks := []Lock{}
+	for id := range strings.FieldsSeq(result.Stdout) {
+		result = r.runner.Run(ctx, "restic", append([]string{"cat", "lock", id, "--no-lock"}, repoArgs...)...)
+
+		err = r.toErr(ctx, result, repo, "cat lock")
+		if err != nil {
+			return nil, err
+		}
+
+		lock := Lock{ID: id}
+		err = json.Unmarshal([]byte(result.Stdout), &lock)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: parse lock %s: %w", repo.Name, id, err)
+		}
+		lock.ID = id
+
+		locks = append(locks, lock)
+	}
+
+	return locks, nil
+}
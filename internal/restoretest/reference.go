@@ -0,0 +1,119 @@
+package restoretest
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+// reference provides the expected checksum of a snapshot file.
+type reference interface {
+	// checksum returns the expected SHA-256 of the file.
+	// ok is false if the file can't be compared.
+	checksum(f restic.Node) (sum string, ok bool)
+}
+
+func newReference(rt entity.RestoreTest) (reference, error) { //nolint:ireturn // reference is chosen by config
+	if rt.Compare == entity.CompareManifest {
+		return loadManifest(rt.Manifest)
+	}
+	return sourceReference{}, nil
+}
+
+// sourceReference compares with the live source file.
+// Files modified since the backup are skipped.
+type sourceReference struct{}
+
+func (sourceReference) checksum(f restic.Node) (string, bool) {
+	info, err := os.Stat(f.Path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	if uint64(info.Size()) != f.Size || !info.ModTime().Equal(f.MTime) { //nolint:gosec // file sizes are never negative
+		return "", false
+	}
+
+	sum, err := fileChecksum(f.Path)
+	if err != nil {
+		return "", false
+	}
+
+	return sum, true
+}
+
+// manifest maps absolute file paths to SHA-256 checksums.
+type manifest map[string]string
+
+func (m manifest) checksum(f restic.Node) (string, bool) {
+	sum, ok := m[f.Path]
+	return sum, ok
+}
+
+// loadManifest reads a checksum file in sha256sum format:
+// "<hex checksum>  <absolute path>" per line.
+func loadManifest(name string) (manifest, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer file.Close()
+
+	return parseManifest(file)
+}
+
+func parseManifest(r io.Reader) (manifest, error) {
+	m := manifest{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("manifest line %d: expected \"<sha256>  <path>\"", n)
+		}
+
+		// sha256sum separates the checksum with " " (text mode) or " *" (binary mode).
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+		if !path.IsAbs(name) {
+			return nil, fmt.Errorf("manifest line %d: path %q is not absolute", n, name)
+		}
+
+		m[name] = strings.ToLower(sum)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+func fileChecksum(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,229 @@
+// Package restoretest verifies that files of a backup can actually be restored.
+package restoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+)
+
+// maxListedMismatches limits how many differing files are named in an error.
+const maxListedMismatches = 5
+
+// Report summarizes a restore test of a single job.
+type Report struct {
+	Snapshot   string   // Short ID of the tested snapshot
+	Verified   int      // Files whose content matched the reference checksum
+	Skipped    int      // Files that could not be compared (source changed, not in manifest)
+	Mismatched []string // Files whose restored content differs from the reference
+}
+
+// Tester restores files of the latest snapshot of a job to a temporary
+// directory and compares their checksums with the live source or a manifest.
+type Tester struct {
+	restic *restic.Service
+}
+
+func NewTester(restic *restic.Service) *Tester {
+	return &Tester{restic: restic}
+}
+
+// Run performs the restore test of a backup job.
+// An error is returned if the restore fails, any file differs or no file could be verified.
+func (t *Tester) Run(ctx context.Context, job entity.BackupJob, rt entity.RestoreTest) (*Report, error) {
+	ctx = logger.WithRepoFields(ctx, job.To)
+	log := logger.FromContext(ctx).With().Str("job", job.Name).Logger()
+	ctx = log.WithContext(ctx)
+
+	snapshot, err := t.latestSnapshot(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	log = log.With().Str("snapshot", snapshot.ShortID).Logger()
+	log.Info().Msg("Starting restore test")
+
+	nodes, err := t.restic.Ls(ctx, job.To, snapshot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := selectFiles(nodes, resolvePaths(rt.Paths, job.From), rt.Sample)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", snapshot.ShortID, err)
+	}
+
+	reference, err := newReference(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "crestic-restore-test-")
+	if err != nil {
+		return nil, fmt.Errorf("create restore directory: %w", err)
+	}
+	defer func() {
+		rmErr := os.RemoveAll(tmp)
+		if rmErr != nil {
+			log.Warn().Err(rmErr).Str("dir", tmp).Msg("Failed to remove restore test directory")
+		}
+	}()
+
+	err = t.restic.Restore(ctx, job.To, snapshot.ID, restic.RestoreOptions{
+		Target:  tmp,
+		Include: lo.Map(files, func(n restic.Node, _ int) string { return restic.EscapePattern(n.Path) }),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Snapshot: snapshot.ShortID}
+	for _, f := range files {
+		verifyFile(ctx, reference, tmp, f, report)
+	}
+
+	log = log.With().
+		Int("verified", report.Verified).
+		Int("skipped", report.Skipped).
+		Int("mismatched", len(report.Mismatched)).
+		Logger()
+
+	err = report.err()
+	if err != nil {
+		log.Error().Msg("Restore test failed")
+		return report, err
+	}
+
+	log.Info().Msg("Restore test passed")
+	return report, nil
+}
+
+func (t *Tester) latestSnapshot(ctx context.Context, job entity.BackupJob) (restic.Snapshot, error) {
+	snapshots, err := t.restic.Snapshots(ctx, job.To, job.SnapshotFilter(), "latest")
+	if err != nil {
+		return restic.Snapshot{}, err
+	}
+
+	if len(snapshots) == 0 {
+		return restic.Snapshot{}, fmt.Errorf("no snapshots found in repository %s", job.To.Name)
+	}
+
+	return lo.MaxBy(snapshots, func(a, b restic.Snapshot) bool {
+		return a.Time.After(b.Time)
+	}), nil
+}
+
+func (r *Report) err() error {
+	if len(r.Mismatched) > 0 {
+		listed := r.Mismatched[:min(len(r.Mismatched), maxListedMismatches)]
+		msg := strings.Join(listed, ", ")
+		if len(r.Mismatched) > len(listed) {
+			msg += fmt.Sprintf(" and %d more", len(r.Mismatched)-len(listed))
+		}
+		return fmt.Errorf(
+			"snapshot %s: %d of %d restored files differ: %s",
+			r.Snapshot, len(r.Mismatched), len(r.Mismatched)+r.Verified+r.Skipped, msg,
+		)
+	}
+
+	if r.Verified == 0 {
+		return fmt.Errorf("snapshot %s: no file could be verified (%d skipped)", r.Snapshot, r.Skipped)
+	}
+
+	return nil
+}
+
+// resolvePaths makes configured paths absolute.
+// Relative paths are resolved against each of the job's source directories.
+func resolvePaths(paths, sources []string) []string {
+	var result []string
+	for _, p := range paths {
+		if path.IsAbs(p) {
+			result = append(result, path.Clean(p))
+			continue
+		}
+
+		for _, src := range sources {
+			result = append(result, path.Join(src, p))
+		}
+	}
+
+	return result
+}
+
+// selectFiles returns all files below the given paths plus up to sample random other files.
+func selectFiles(nodes []restic.Node, paths []string, sample int) ([]restic.Node, error) {
+	files := lo.Filter(nodes, func(n restic.Node, _ int) bool {
+		return n.Type == "file"
+	})
+
+	var selected, rest []restic.Node
+	for _, f := range files {
+		if slices.ContainsFunc(paths, func(p string) bool { return isWithin(f.Path, p) }) {
+			selected = append(selected, f)
+			continue
+		}
+		rest = append(rest, f)
+	}
+
+	for _, p := range paths {
+		if !slices.ContainsFunc(selected, func(f restic.Node) bool { return isWithin(f.Path, p) }) {
+			return nil, fmt.Errorf("path %s contains no files", p)
+		}
+	}
+
+	//nolint:gosec // sampling doesn't need a cryptographically secure source
+	for _, i := range rand.Perm(len(rest))[:min(sample, len(rest))] {
+		selected = append(selected, rest[i])
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("no files to verify")
+	}
+
+	return selected, nil
+}
+
+func isWithin(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+}
+
+func verifyFile(ctx context.Context, ref reference, dir string, f restic.Node, report *Report) {
+	log := logger.FromContext(ctx).With().Str("file", f.Path).Logger()
+
+	restored := filepath.Join(dir, filepath.FromSlash(f.Path))
+	info, err := os.Stat(restored)
+	if err != nil || uint64(info.Size()) != f.Size { //nolint:gosec // file sizes are never negative
+		log.Error().Err(err).Msg("File was not restored correctly")
+		report.Mismatched = append(report.Mismatched, f.Path)
+		return
+	}
+
+	expected, ok := ref.checksum(f)
+	if !ok {
+		log.Debug().Msg("No reference checksum, skipping")
+		report.Skipped++
+		return
+	}
+
+	actual, err := fileChecksum(restored)
+	if err != nil || actual != expected {
+		log.Error().Err(err).Msg("Restored file content differs")
+		report.Mismatched = append(report.Mismatched, f.Path)
+		return
+	}
+
+	report.Verified++
+}
@@ -0,0 +1,209 @@
+package restoretest_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/restic"
+	"github.com/alexander-kolodka/crestic/internal/restoretest"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// fakeRestic serves a single snapshot of the files in src, taken by newFake.
+// Restored content can be altered through corrupt.
+type fakeRestic struct {
+	src      string
+	files    map[string]string
+	listing  string
+	corrupt  map[string]string
+	restored []string
+}
+
+func (f *fakeRestic) Run(_ context.Context, _ string, args ...string) *shell.Result {
+	switch args[0] {
+	case "snapshots":
+		return &shell.Result{Stdout: `[{"id":"abcdef1234","short_id":"abcdef12","time":"2026-01-01T00:00:00Z","paths":["` + f.src + `"]}]`}
+	case "ls":
+		return &shell.Result{Stdout: f.listing}
+	case "restore":
+		return f.restore(args)
+	default:
+		return &shell.Result{ExitCode: 1, Error: fmt.Errorf("unexpected command %s", args[0])}
+	}
+}
+
+func (f *fakeRestic) ls() string {
+	lines := []string{`{"struct_type":"snapshot","id":"abcdef1234"}`}
+	lines = append(lines, fmt.Sprintf(`{"struct_type":"node","type":"dir","path":%q}`, f.src))
+	for name := range f.files {
+		p := filepath.Join(f.src, name)
+		info, _ := os.Stat(p)
+		node, _ := json.Marshal(restic.Node{Type: "file", Path: p, Size: uint64(info.Size()), MTime: info.ModTime()})
+		lines = append(lines, strings.Replace(string(node), "{", `{"struct_type":"node",`, 1))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (f *fakeRestic) restore(args []string) *shell.Result {
+	target := args[slices.Index(args, "--target")+1]
+	for i, a := range args {
+		if a != "--include" {
+			continue
+		}
+
+		p := args[i+1]
+		content := f.files[strings.TrimPrefix(p, f.src+"/")]
+		if c, ok := f.corrupt[strings.TrimPrefix(p, f.src+"/")]; ok {
+			content = c
+		}
+
+		dst := filepath.Join(target, p)
+		_ = os.MkdirAll(filepath.Dir(dst), 0o700)
+		_ = os.WriteFile(dst, []byte(content), 0o600)
+		f.restored = append(f.restored, p)
+	}
+	return &shell.Result{}
+}
+
+func newFake(t *testing.T) *fakeRestic {
+	t.Helper()
+
+	f := &fakeRestic{
+		src: t.TempDir(),
+		files: map[string]string{
+			"a.txt":        "alpha",
+			"docs/b.txt":   "bravo",
+			"docs/c.txt":   "charlie",
+			"photos/d.jpg": "delta",
+		},
+	}
+
+	for name, content := range f.files {
+		p := filepath.Join(f.src, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o700))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+
+	f.listing = f.ls()
+	return f
+}
+
+func newJob(f *fakeRestic) entity.BackupJob {
+	return entity.BackupJob{
+		Name: "docs",
+		From: []string{f.src},
+		To:   &entity.Repository{Name: "local", Path: "/repo"},
+	}
+}
+
+func TestRunComparesWithSource(t *testing.T) {
+	f := newFake(t)
+	tester := restoretest.NewTester(restic.NewService(f))
+
+	report, err := tester.Run(context.Background(), newJob(f), entity.RestoreTest{
+		Sample:  1,
+		Paths:   []string{"docs"},
+		Compare: entity.CompareSource,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "abcdef12", report.Snapshot)
+	assert.Equal(t, 3, report.Verified)
+	assert.Len(t, f.restored, 3)
+	assert.Contains(t, f.restored, filepath.Join(f.src, "docs/b.txt"))
+	assert.Contains(t, f.restored, filepath.Join(f.src, "docs/c.txt"))
+}
+
+func TestRunDetectsCorruptedFile(t *testing.T) {
+	f := newFake(t)
+	f.corrupt = map[string]string{"docs/b.txt": "BRAVO"}
+	tester := restoretest.NewTester(restic.NewService(f))
+
+	report, err := tester.Run(context.Background(), newJob(f), entity.RestoreTest{
+		Paths:   []string{filepath.Join(f.src, "docs")},
+		Compare: entity.CompareSource,
+	})
+	require.ErrorContains(t, err, "1 of 2 restored files differ")
+	assert.Equal(t, []string{filepath.Join(f.src, "docs/b.txt")}, report.Mismatched)
+}
+
+func TestRunSkipsChangedSourceFiles(t *testing.T) {
+	f := newFake(t)
+	tester := restoretest.NewTester(restic.NewService(f))
+
+	changed := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(f.src, "a.txt"), changed, changed))
+
+	report, err := tester.Run(context.Background(), newJob(f), entity.RestoreTest{
+		Paths:   []string{"a.txt", "docs/b.txt"},
+		Compare: entity.CompareSource,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Verified)
+	assert.Equal(t, 1, report.Skipped)
+
+	_, err = tester.Run(context.Background(), newJob(f), entity.RestoreTest{
+		Paths:   []string{"a.txt"},
+		Compare: entity.CompareSource,
+	})
+	require.ErrorContains(t, err, "no file could be verified (1 skipped)")
+}
+
+func TestRunComparesWithManifest(t *testing.T) {
+	f := newFake(t)
+	tester := restoretest.NewTester(restic.NewService(f))
+
+	manifest := writeManifest(t, map[string]string{
+		filepath.Join(f.src, "a.txt"):        "alpha",
+		filepath.Join(f.src, "photos/d.jpg"): "a different photo",
+	})
+
+	report, err := tester.Run(context.Background(), newJob(f), entity.RestoreTest{
+		Sample:   10,
+		Compare:  entity.CompareManifest,
+		Manifest: manifest,
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, report.Verified)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, []string{filepath.Join(f.src, "photos/d.jpg")}, report.Mismatched)
+}
+
+func TestRunFailsForUnknownPath(t *testing.T) {
+	f := newFake(t)
+	tester := restoretest.NewTester(restic.NewService(f))
+
+	_, err := tester.Run(context.Background(), newJob(f), entity.RestoreTest{
+		Paths:   []string{"missing"},
+		Compare: entity.CompareSource,
+	})
+	require.ErrorContains(t, err, "contains no files")
+	assert.Empty(t, f.restored)
+}
+
+func writeManifest(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	var b strings.Builder
+	for p, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(sum[:]), p)
+	}
+
+	name := filepath.Join(t.TempDir(), "manifest.sha256")
+	require.NoError(t, os.WriteFile(name, []byte(b.String()), 0o600))
+	return name
+}
@@ -0,0 +1,62 @@
+package runhistory
+
+import (
+	"time"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+)
+
+// Status is the outcome of a run or a job.
+type Status string
+
+const (
+	StatusSuccess Status = "success"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Run is a single crestic invocation that processed one or more jobs.
+type Run struct {
+	ID     string    `json:"id"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Status Status    `json:"status"`
+	Jobs   []Job     `json:"jobs"`
+}
+
+// Job is the recorded outcome of a single job within a run.
+type Job struct {
+	Name          string              `json:"name"`
+	Type          string              `json:"type"`
+	Repository    string              `json:"repository,omitempty"`
+	Start         time.Time           `json:"start"`
+	End           time.Time           `json:"end"`
+	Status        Status              `json:"status"`
+	Error         string              `json:"error,omitempty"`
+	SkippedReason string              `json:"skipped_reason,omitempty"`
+	SnapshotID    string              `json:"snapshot_id,omitempty"`
+	Stats         *entity.BackupStats `json:"stats,omitempty"`
+}
+
+// NewRun creates a run record started at the given time.
+func NewRun(id string, start time.Time) *Run {
+	return &Run{
+		ID:     id,
+		Start:  start,
+		Status: StatusSuccess,
+		Jobs:   []Job{},
+	}
+}
+
+// AddJob appends a job record. A failed job marks the whole run as failed.
+func (r *Run) AddJob(j Job) {
+	r.Jobs = append(r.Jobs, j)
+	if j.Status == StatusFailed {
+		r.Status = StatusFailed
+	}
+}
+
+// Finish sets the end time of the run.
+func (r *Run) Finish(end time.Time) {
+	r.End = end
+}
@@ -0,0 +1,155 @@
+package runhistory
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	fileName    = "history.db"
+	openTimeout = 5 * time.Second
+)
+
+var runsBucket = []byte("runs") //nolint:gochecknoglobals // bbolt bucket names are byte slices
+
+// Store persists runs in an embedded bbolt database.
+// The database is opened only for the duration of each operation,
+// so several crestic processes can share it.
+type Store struct {
+	path string
+}
+
+// Filter restricts the runs returned by List.
+type Filter struct {
+	Job   string    // Keep only records of this job
+	Since time.Time // Keep only runs started at or after this time
+}
+
+// NewStore creates a Store backed by the database file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath returns ~/.crestic/history.db.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".crestic", fileName), nil
+}
+
+// Save stores the run, replacing any run with the same ID and start time.
+func (s *Store) Save(run *Run) error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0o750)
+	if err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("open history %s: %w", s.path, err)
+	}
+	defer db.Close()
+
+	value, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("marshal run: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, bErr := tx.CreateBucketIfNotExists(runsBucket)
+		if bErr != nil {
+			return bErr
+		}
+
+		return b.Put(runKey(run), value)
+	})
+	if err != nil {
+		return fmt.Errorf("save run %s: %w", run.ID, err)
+	}
+
+	return nil
+}
+
+// List returns runs matching the filter, oldest first.
+// When filtering by job, runs only contain records of that job.
+func (s *Store) List(filter Filter) ([]Run, error) {
+	_, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Run{}, nil
+	}
+
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: openTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("open history %s: %w", s.path, err)
+	}
+	defer db.Close()
+
+	runs := []Run{}
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(runsBucket)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Seek(timeKey(filter.Since)); k != nil; k, v = c.Next() {
+			var run Run
+			uErr := json.Unmarshal(v, &run)
+			if uErr != nil {
+				return fmt.Errorf("unmarshal run %x: %w", k, uErr)
+			}
+
+			if filterJobs(&run, filter.Job) {
+				runs = append(runs, run)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// filterJobs drops records of other jobs and reports whether the run should be kept.
+func filterJobs(run *Run, job string) bool {
+	if job == "" {
+		return true
+	}
+
+	jobs := make([]Job, 0, len(run.Jobs))
+	for _, j := range run.Jobs {
+		if j.Name == job {
+			jobs = append(jobs, j)
+		}
+	}
+
+	run.Jobs = jobs
+	return len(jobs) > 0
+}
+
+// runKey orders runs by start time; the ID keeps keys of concurrent runs unique.
+func runKey(run *Run) []byte {
+	return append(timeKey(run.Start), run.ID...)
+}
+
+func timeKey(t time.Time) []byte {
+	const size = 8
+	key := make([]byte, size)
+	if !t.IsZero() && t.UnixNano() > 0 {
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	}
+	return key
+}
@@ -0,0 +1,72 @@
+package runhistory_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/entity"
+	"github.com/alexander-kolodka/crestic/internal/runhistory"
+)
+
+func newRun(id string, start time.Time, jobs ...runhistory.Job) *runhistory.Run {
+	run := runhistory.NewRun(id, start)
+	for _, j := range jobs {
+		run.AddJob(j)
+	}
+	run.Finish(start.Add(time.Minute))
+	return run
+}
+
+func TestStoreListEmpty(t *testing.T) {
+	store := runhistory.NewStore(filepath.Join(t.TempDir(), "history.db"))
+
+	runs, err := store.List(runhistory.Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestStoreSaveAndList(t *testing.T) {
+	store := runhistory.NewStore(filepath.Join(t.TempDir(), "nested", "history.db"))
+	now := time.Now().Truncate(time.Second)
+
+	docs := runhistory.Job{
+		Name:       "documents",
+		Type:       "backup",
+		Status:     runhistory.StatusSuccess,
+		SnapshotID: "abcdef0123456789",
+		Stats:      &entity.BackupStats{DataAdded: 1024, TotalBytesProcessed: 4096},
+	}
+	photos := runhistory.Job{
+		Name:   "photos",
+		Type:   "backup",
+		Status: runhistory.StatusFailed,
+		Error:  "repository offline",
+	}
+
+	require.NoError(t, store.Save(newRun("new", now, docs)))
+	require.NoError(t, store.Save(newRun("old", now.Add(-10*24*time.Hour), docs, photos)))
+
+	runs, err := store.List(runhistory.Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "old", runs[0].ID)
+	assert.Equal(t, runhistory.StatusFailed, runs[0].Status)
+	assert.Equal(t, "new", runs[1].ID)
+	assert.Equal(t, runhistory.StatusSuccess, runs[1].Status)
+	assert.Equal(t, uint64(1024), runs[1].Jobs[0].Stats.DataAdded)
+
+	runs, err = store.List(runhistory.Filter{Since: now.Add(-7 * 24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "new", runs[0].ID)
+
+	runs, err = store.List(runhistory.Filter{Job: "photos"})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Len(t, runs[0].Jobs, 1)
+	assert.Equal(t, "repository offline", runs[0].Jobs[0].Error)
+}
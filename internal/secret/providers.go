@@ -0,0 +1,139 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// passStore reads secrets from the pass password store: secret://pass/<entry>[#field].
+// Without a field the first line of the entry is the secret,
+// otherwise the value of the "field: value" line.
+type passStore struct {
+	runner runner
+}
+
+func (p *passStore) Secret(ctx context.Context, ref Ref) (string, error) {
+	out, err := run(ctx, p.runner, "pass", "show", ref.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Key == "" {
+		first, _, _ := strings.Cut(out, "\n")
+		return strings.TrimRight(first, "\r"), nil
+	}
+
+	return field(out, ref.Key)
+}
+
+// onePassword reads secrets with the 1Password CLI: secret://op/<vault>/<item>[/<section>]/<field>.
+// The key, if given, is appended as the field.
+type onePassword struct {
+	runner runner
+}
+
+func (p *onePassword) Secret(ctx context.Context, ref Ref) (string, error) {
+	path := ref.Path
+	if ref.Key != "" {
+		path += "/" + ref.Key
+	}
+
+	out, err := run(ctx, p.runner, "op", "read", "--no-newline", "op://"+path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(out, "\r\n"), nil
+}
+
+// vaultKV reads a field of a HashiCorp Vault KV secret: secret://vault/<mount>/<path>#<field>.
+// The Vault address and token are taken from the usual VAULT_* environment variables.
+type vaultKV struct {
+	runner runner
+}
+
+func (p *vaultKV) Secret(ctx context.Context, ref Ref) (string, error) {
+	if ref.Key == "" {
+		return "", errors.New("vault secrets require a field, e.g. secret://vault/kv/backup#password")
+	}
+
+	out, err := run(ctx, p.runner, "vault", "kv", "get", "-field="+ref.Key, ref.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(out, "\r\n"), nil
+}
+
+// keyring reads secrets from the system keyring: secret://keyring/<service>/<account>.
+// macOS Keychain is used on macOS, the Secret Service (secret-tool) elsewhere.
+type keyring struct {
+	runner runner
+}
+
+func (p *keyring) Secret(ctx context.Context, ref Ref) (string, error) {
+	service, account, ok := strings.Cut(ref.Path, "/")
+	if !ok || service == "" || account == "" {
+		return "", errors.New("keyring secrets must be in the form secret://keyring/<service>/<account>")
+	}
+
+	var out string
+	var err error
+	if runtime.GOOS == "darwin" {
+		out, err = run(ctx, p.runner, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	} else {
+		out, err = run(ctx, p.runner, "secret-tool", "lookup", "service", service, "account", account)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(out, "\r\n"), nil
+}
+
+// fileStore reads secrets from local files: secret://file/<absolute path>[#field].
+// Without a field the whole file without the trailing newline is the secret,
+// otherwise the value of the "field: value" line, as with pass.
+type fileStore struct{}
+
+func (fileStore) Secret(_ context.Context, ref Ref) (string, error) {
+	b, err := os.ReadFile("/" + ref.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Key == "" {
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+
+	return field(string(b), ref.Key)
+}
+
+// field returns the value of the "key: value" line in s.
+func field(s, key string) (string, error) {
+	for line := range strings.Lines(s) {
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v), nil
+		}
+	}
+
+	return "", fmt.Errorf("field %s not found", key)
+}
+
+func run(ctx context.Context, r runner, service string, args ...string) (string, error) {
+	ctx = logger.WithSource(ctx, "secret")
+	result := r.Run(shell.WithSilence(ctx), service, args...)
+	if result.Error != nil {
+		return "", fmt.Errorf("%s failed [exit code %d]: %w", service, result.ExitCode, result.Error)
+	}
+
+	return result.Stdout, nil
+}
@@ -0,0 +1,147 @@
+// Package secret resolves secret references such as secret://pass/backups/nas
+// through pluggable providers, so configs don't depend on per-machine password commands.
+package secret
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/alexander-kolodka/crestic/internal/redact"
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+// Scheme is the prefix of secret references.
+const Scheme = "secret://"
+
+// Ref is a parsed secret reference: secret://<provider>/<path>[#<key>].
+type Ref struct {
+	Provider string // Provider name, e.g. pass or vault
+	Path     string // Provider-specific location of the secret, without the leading slash
+	Key      string // Optional field of the secret
+}
+
+// String returns the reference in its secret:// form.
+func (r Ref) String() string {
+	s := Scheme + r.Provider + "/" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s
+}
+
+// IsRef reports whether s is a secret reference rather than a plain value.
+func IsRef(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// Parse parses a secret reference.
+func Parse(s string) (Ref, error) {
+	if !IsRef(s) {
+		return Ref{}, fmt.Errorf("secret reference %q must start with %s", s, Scheme)
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return Ref{}, fmt.Errorf("secret reference %q: %w", s, err)
+	}
+
+	ref := Ref{
+		Provider: u.Host,
+		Path:     strings.TrimPrefix(u.Path, "/"),
+		Key:      u.Fragment,
+	}
+	if ref.Provider == "" || ref.Path == "" {
+		return Ref{}, fmt.Errorf("secret reference %q must be in the form %s<provider>/<path>[#key]", s, Scheme)
+	}
+
+	return ref, nil
+}
+
+// Provider fetches secrets from a secret store.
+type Provider interface {
+	Secret(ctx context.Context, ref Ref) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func(ctx context.Context, ref Ref) (string, error)
+
+// Secret calls f.
+func (f ProviderFunc) Secret(ctx context.Context, ref Ref) (string, error) {
+	return f(ctx, ref)
+}
+
+type runner interface {
+	Run(ctx context.Context, service string, args ...string) *shell.Result
+}
+
+// Resolver resolves secret references through the registered providers.
+// Every reference is resolved once per process; resolved values are masked in logs.
+type Resolver struct {
+	providers map[string]Provider
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver creates a Resolver with the built-in providers:
+// pass, op (1Password CLI), vault, keyring and file.
+func NewResolver(runner runner) *Resolver {
+	r := &Resolver{
+		providers: make(map[string]Provider),
+		cache:     make(map[string]string),
+	}
+
+	r.Register("pass", &passStore{runner: runner})
+	r.Register("op", &onePassword{runner: runner})
+	r.Register("vault", &vaultKV{runner: runner})
+	r.Register("keyring", &keyring{runner: runner})
+	r.Register("file", fileStore{})
+
+	return r
+}
+
+// Register adds a provider under name, replacing an existing one.
+func (r *Resolver) Register(name string, p Provider) {
+	r.providers[name] = p
+}
+
+// Resolve returns the secret s refers to. Plain values are returned unchanged.
+func (r *Resolver) Resolve(ctx context.Context, s string) (string, error) {
+	if !IsRef(s) {
+		return s, nil
+	}
+
+	ref, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, ok := r.cache[s]
+	if ok {
+		return value, nil
+	}
+
+	p, ok := r.providers[ref.Provider]
+	if !ok {
+		return "", fmt.Errorf("secret %s: unknown provider %q", s, ref.Provider)
+	}
+
+	value, err = p.Secret(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", s, err)
+	}
+	if value == "" {
+		return "", fmt.Errorf("secret %s is empty", s)
+	}
+
+	redact.AddSecrets(value)
+	r.cache[s] = value
+
+	return value, nil
+}
@@ -0,0 +1,189 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+type fakeRunner struct {
+	calls  [][]string
+	stdout string
+	err    error
+}
+
+func (f *fakeRunner) Run(_ context.Context, service string, args ...string) *shell.Result {
+	f.calls = append(f.calls, append([]string{service}, args...))
+	if f.err != nil {
+		return &shell.Result{ExitCode: 1, Error: f.err}
+	}
+	return &shell.Result{Stdout: f.stdout}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Ref
+		err      bool
+	}{
+		{in: "secret://pass/backups/nas", expected: Ref{Provider: "pass", Path: "backups/nas"}},
+		{in: "secret://vault/kv/backup#password", expected: Ref{Provider: "vault", Path: "kv/backup", Key: "password"}},
+		{in: "secret://keyring/crestic/repo", expected: Ref{Provider: "keyring", Path: "crestic/repo"}},
+		{in: "secret://file/etc/crestic/nas", expected: Ref{Provider: "file", Path: "etc/crestic/nas"}},
+		{in: "pass/backups/nas", err: true},
+		{in: "secret://pass", err: true},
+		{in: "secret:///backups/nas", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ref, err := Parse(tt.in)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ref)
+			assert.Equal(t, tt.in, ref.String())
+		})
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nas"), []byte("s3cret\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "s3"), []byte("access: AKIA\nsecret: abc123\n"), 0o600))
+	r := NewResolver(&fakeRunner{})
+
+	value, err := r.Resolve(context.Background(), "secret://file"+filepath.Join(dir, "nas"))
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	value, err = r.Resolve(context.Background(), "secret://file"+filepath.Join(dir, "s3")+"#secret")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", value)
+
+	_, err = r.Resolve(context.Background(), "secret://file"+filepath.Join(dir, "s3")+"#token")
+	require.ErrorContains(t, err, "field token not found")
+
+	_, err = r.Resolve(context.Background(), "secret://file"+filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestResolvePlainValue(t *testing.T) {
+	r := NewResolver(&fakeRunner{})
+
+	value, err := r.Resolve(context.Background(), "eu-central-1")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-central-1", value)
+}
+
+func TestResolveCachesSecrets(t *testing.T) {
+	calls := 0
+	r := NewResolver(&fakeRunner{})
+	r.Register("test", ProviderFunc(func(_ context.Context, ref Ref) (string, error) {
+		calls++
+		return "value-of-" + ref.Path, nil
+	}))
+
+	for range 2 {
+		value, err := r.Resolve(context.Background(), "secret://test/repo")
+		require.NoError(t, err)
+		assert.Equal(t, "value-of-repo", value)
+	}
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestResolveErrors(t *testing.T) {
+	r := NewResolver(&fakeRunner{})
+	r.Register("empty", ProviderFunc(func(context.Context, Ref) (string, error) {
+		return "", nil
+	}))
+
+	_, err := r.Resolve(context.Background(), "secret://unknown/repo")
+	require.ErrorContains(t, err, `unknown provider "unknown"`)
+
+	_, err = r.Resolve(context.Background(), "secret://empty/repo")
+	require.ErrorContains(t, err, "secret secret://empty/repo is empty")
+}
+
+func TestProviderCommands(t *testing.T) {
+	keyringCall := []string{"secret-tool", "lookup", "service", "crestic", "account", "repo"}
+	if runtime.GOOS == "darwin" {
+		keyringCall = []string{"security", "find-generic-password", "-s", "crestic", "-a", "repo", "-w"}
+	}
+
+	tests := []struct {
+		ref      string
+		stdout   string
+		call     []string
+		expected string
+	}{
+		{
+			ref:      "secret://pass/backups/nas",
+			stdout:   "s3cret\nuser: backup\n",
+			call:     []string{"pass", "show", "backups/nas"},
+			expected: "s3cret",
+		},
+		{
+			ref:      "secret://pass/backups/nas#user",
+			stdout:   "s3cret\nuser: backup\n",
+			call:     []string{"pass", "show", "backups/nas"},
+			expected: "backup",
+		},
+		{
+			ref:      "secret://op/Private/restic/password",
+			stdout:   "s3cret",
+			call:     []string{"op", "read", "--no-newline", "op://Private/restic/password"},
+			expected: "s3cret",
+		},
+		{
+			ref:      "secret://vault/kv/backup#password",
+			stdout:   "s3cret\n",
+			call:     []string{"vault", "kv", "get", "-field=password", "kv/backup"},
+			expected: "s3cret",
+		},
+		{
+			ref:      "secret://keyring/crestic/repo",
+			stdout:   "s3cret\n",
+			call:     keyringCall,
+			expected: "s3cret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			runner := &fakeRunner{stdout: tt.stdout}
+			r := NewResolver(runner)
+
+			value, err := r.Resolve(context.Background(), tt.ref)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, value)
+			assert.Equal(t, [][]string{tt.call}, runner.calls)
+		})
+	}
+}
+
+func TestProviderCommandFails(t *testing.T) {
+	r := NewResolver(&fakeRunner{err: errors.New("not found")})
+
+	_, err := r.Resolve(context.Background(), "secret://pass/backups/nas")
+	require.ErrorContains(t, err, "secret secret://pass/backups/nas: pass failed [exit code 1]: not found")
+
+	_, err = r.Resolve(context.Background(), "secret://vault/kv/backup")
+	require.ErrorContains(t, err, "vault secrets require a field")
+
+	_, err = r.Resolve(context.Background(), "secret://keyring/crestic")
+	require.ErrorContains(t, err, "keyring secrets must be in the form")
+}
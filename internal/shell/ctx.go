@@ -1,6 +1,11 @@
 package shell
 
-import "context"
+import (
+	"context"
+	"io"
+	"maps"
+	"time"
+)
 
 type printCommands struct{}
 
@@ -8,6 +13,14 @@ type silent struct{}
 
 type envVars struct{}
 
+type output struct{}
+
+type dir struct{}
+
+type timeout struct{}
+
+type gracePeriod struct{}
+
 func WithPrintingCommands(ctx context.Context) context.Context {
 	return context.WithValue(ctx, printCommands{}, true)
 }
@@ -16,8 +29,38 @@ func WithSilence(ctx context.Context) context.Context {
 	return context.WithValue(ctx, silent{}, true)
 }
 
+// WithEnv adds environment variables to the commands started with ctx.
+// Variables set earlier in ctx are kept unless overridden by env.
 func WithEnv(ctx context.Context, env map[string]string) context.Context {
-	return context.WithValue(ctx, envVars{}, env)
+	merged := maps.Clone(getEnvVars(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(env))
+	}
+	maps.Copy(merged, env)
+
+	return context.WithValue(ctx, envVars{}, merged)
+}
+
+// WithOutput redirects command stdout and stderr to w instead of the logger.
+// w must be safe for concurrent use, as stdout and stderr are written concurrently.
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, output{}, w)
+}
+
+// WithDir runs commands in the given working directory instead of the current one.
+func WithDir(ctx context.Context, d string) context.Context {
+	return context.WithValue(ctx, dir{}, d)
+}
+
+// WithTimeout limits the run time of every single command started with ctx.
+// A zero duration means no limit.
+func WithTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeout{}, d)
+}
+
+// WithGracePeriod sets the time canceled commands get to exit before they're killed.
+func WithGracePeriod(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, gracePeriod{}, d)
 }
 
 func shouldPrintCommands(ctx context.Context) bool {
@@ -37,3 +80,35 @@ func getEnvVars(ctx context.Context) map[string]string {
 	}
 	return env
 }
+
+func getOutput(ctx context.Context) io.Writer {
+	w, ok := ctx.Value(output{}).(io.Writer)
+	if !ok {
+		return nil
+	}
+	return w
+}
+
+func getDir(ctx context.Context) string {
+	d, ok := ctx.Value(dir{}).(string)
+	if !ok {
+		return ""
+	}
+	return d
+}
+
+func getTimeout(ctx context.Context) time.Duration {
+	d, ok := ctx.Value(timeout{}).(time.Duration)
+	if !ok {
+		return 0
+	}
+	return d
+}
+
+func getGracePeriod(ctx context.Context) time.Duration {
+	d, ok := ctx.Value(gracePeriod{}).(time.Duration)
+	if !ok || d <= 0 {
+		return DefaultGracePeriod
+	}
+	return d
+}
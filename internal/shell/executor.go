@@ -9,10 +9,12 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 
 	"github.com/alexander-kolodka/crestic/internal/logger"
+	"github.com/alexander-kolodka/crestic/internal/redact"
 )
 
 // Executor runs shell commands with full stdout/stderr logging.
@@ -25,6 +27,7 @@ type Result struct {
 	Stdout   string
 	Stderr   string
 	Error    error
+	TimedOut bool // The command was killed because its timeout or the context deadline was exceeded
 }
 
 // NewExecutor creates a new Executor with the given logger.
@@ -35,8 +38,20 @@ func NewExecutor() *Executor {
 // Run executes a command with timeout control and handling of ignored exit codes.
 // All stdout/stderr is written to console and logs. Returns Result with exit code and output.
 // If context has silent output enabled, stdout/stderr are suppressed.
+// If context has an output writer, stdout/stderr are written to it instead of the logger.
+// The command runs in its own process group. When it times out or ctx is canceled,
+// the whole group receives SIGTERM, followed by SIGKILL after a grace period.
 func (r *Executor) Run(ctx context.Context, service string, args ...string) *Result {
+	timeout := getTimeout(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	cmd := exec.CommandContext(ctx, service, args...)
+	cmd.Dir = getDir(ctx)
+	killer := newGroupKiller(ctx, cmd)
 
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env,
@@ -53,16 +68,21 @@ func (r *Executor) Run(ctx context.Context, service string, args ...string) *Res
 
 	var stdoutBuf, stderrBuf bytes.Buffer
 
-	if isSilent(ctx) {
+	switch out := getOutput(ctx); {
+	case isSilent(ctx):
 		cmd.Stdout = &stdoutBuf
 		cmd.Stderr = &stderrBuf
-	} else {
+	case out != nil:
+		cmd.Stdout = io.MultiWriter(out, &stdoutBuf)
+		cmd.Stderr = io.MultiWriter(out, &stderrBuf)
+	default:
 		shellWriter := logger.NewShellWriter(ctx)
 		cmd.Stdout = io.MultiWriter(shellWriter, &stdoutBuf)
 		cmd.Stderr = io.MultiWriter(shellWriter, &stderrBuf)
 	}
 
-	err := cmd.Run()
+	err := killer.run()
+	killer.stop()
 
 	result := &Result{
 		ExitCode: 0,
@@ -77,14 +97,27 @@ func (r *Executor) Run(ctx context.Context, service string, args ...string) *Res
 		result.Error = fmt.Errorf(`%s: %s`, exitError.Error(), result.Stderr)
 	}
 
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+		result.Error = timeoutError(timeout, err)
+	}
+
 	return result
 }
 
+func timeoutError(timeout time.Duration, err error) error {
+	if timeout > 0 {
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return fmt.Errorf("timed out: %w", err)
+}
+
+// formatCommand renders the command for printing, with secrets masked.
 func formatCommand(cmd string, args []string) string {
 	b := new(strings.Builder)
-	b.WriteString(cmd)
+	b.WriteString(redact.String(cmd))
 
-	for _, a := range args {
+	for _, a := range redact.Args(args) {
 		b.WriteByte(' ')
 
 		// Quote if contains spaces or special characters
@@ -100,3 +133,8 @@ func formatCommand(cmd string, args []string) string {
 
 	return b.String()
 }
+
+// Quote quotes s for use as a single word in a POSIX shell command.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,87 @@
+package shell_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexander-kolodka/crestic/internal/shell"
+)
+
+func TestRunTimeoutKillsProcessGroup(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	ctx := shell.WithSilence(shell.WithTimeout(context.Background(), 200*time.Millisecond))
+
+	start := time.Now()
+	// The background subshell would survive if only sh itself were killed.
+	result := shell.NewExecutor().Run(ctx, "sh", "-c", "(sleep 1; touch "+marker+") & wait")
+
+	require.Error(t, result.Error)
+	assert.True(t, result.TimedOut)
+	assert.ErrorContains(t, result.Error, "timed out after 200ms")
+	assert.Less(t, time.Since(start), time.Second)
+
+	time.Sleep(1500 * time.Millisecond)
+	assert.NoFileExists(t, marker)
+}
+
+func TestRunWithoutTimeout(t *testing.T) {
+	result := shell.NewExecutor().Run(shell.WithSilence(context.Background()), "sh", "-c", "exit 2")
+
+	require.Error(t, result.Error)
+	assert.False(t, result.TimedOut)
+	assert.Equal(t, 2, result.ExitCode)
+}
+
+func TestRunDir(t *testing.T) {
+	dir := t.TempDir()
+	ctx := shell.WithSilence(shell.WithDir(context.Background(), dir))
+
+	result := shell.NewExecutor().Run(ctx, "touch", "file")
+	require.NoError(t, result.Error)
+
+	_, err := os.Stat(filepath.Join(dir, "file"))
+	require.NoError(t, err)
+}
+
+func TestRunForwardsInterruptSignal(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(shell.WithSilence(context.Background()))
+	time.AfterFunc(200*time.Millisecond, func() {
+		cancel(&shell.InterruptedError{Signal: syscall.SIGINT})
+	})
+
+	result := shell.NewExecutor().Run(ctx, "sh", "-c", `trap 'echo interrupted; kill $!; exit 0' INT; sleep 5 & wait`)
+
+	assert.Equal(t, "interrupted\n", result.Stdout)
+	assert.False(t, result.TimedOut)
+}
+
+func TestKillAll(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	done := make(chan *shell.Result, 1)
+	go func() {
+		done <- shell.NewExecutor().Run(shell.WithSilence(context.Background()),
+			"sh", "-c", "(sleep 1; touch "+marker+") & wait")
+	}()
+
+	var result *shell.Result
+	require.Eventually(t, func() bool {
+		shell.KillAll()
+		select {
+		case result = <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	require.Error(t, result.Error)
+	time.Sleep(1500 * time.Millisecond)
+	assert.NoFileExists(t, marker, "the whole process group is killed")
+}
@@ -0,0 +1,24 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// InterruptedError is the cancellation cause of a context canceled by a signal.
+// Running commands receive the same signal.
+type InterruptedError struct {
+	Signal os.Signal
+}
+
+func (e *InterruptedError) Error() string {
+	return "interrupted by " + e.Signal.String()
+}
+
+// Interrupted returns the signal error if ctx was canceled by a signal.
+func Interrupted(ctx context.Context) (*InterruptedError, bool) {
+	var ie *InterruptedError
+	ok := errors.As(context.Cause(ctx), &ie)
+	return ie, ok
+}
@@ -0,0 +1,98 @@
+package shell
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultGracePeriod is the time a canceled command's process group gets
+// to exit after SIGTERM before it's killed with SIGKILL.
+const DefaultGracePeriod = 10 * time.Second
+
+// liveGroups holds the process groups of running commands.
+var liveGroups = struct {
+	sync.Mutex
+	pgids map[int]struct{}
+}{pgids: make(map[int]struct{})}
+
+// KillAll sends SIGKILL to the process groups of all running commands.
+// Commands run in their own process groups, so they don't receive terminal signals
+// and would outlive crestic if it exits without waiting for them.
+func KillAll() {
+	liveGroups.Lock()
+	defer liveGroups.Unlock()
+
+	for pgid := range liveGroups.pgids {
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+}
+
+// groupKiller terminates the whole process group of a command on cancellation,
+// so children started by sh -c don't outlive it.
+type groupKiller struct {
+	ctx   context.Context
+	cmd   *exec.Cmd
+	grace time.Duration
+	timer *time.Timer
+}
+
+func newGroupKiller(ctx context.Context, cmd *exec.Cmd) *groupKiller {
+	k := &groupKiller{ctx: ctx, cmd: cmd, grace: getGracePeriod(ctx)}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = k.terminate
+	// Closes stdout/stderr if processes that inherited them survive SIGKILL of the group.
+	cmd.WaitDelay = k.grace + time.Second
+
+	return k
+}
+
+// terminate signals the process group and sends SIGKILL after the grace period.
+// A signal that interrupted crestic is forwarded as is, otherwise SIGTERM is sent.
+func (k *groupKiller) terminate() error {
+	sig := syscall.SIGTERM
+	ie, ok := Interrupted(k.ctx)
+	if ok {
+		s, isSyscall := ie.Signal.(syscall.Signal)
+		if isSyscall {
+			sig = s
+		}
+	}
+
+	pgid := k.cmd.Process.Pid
+	k.timer = time.AfterFunc(k.grace, func() {
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	})
+	return syscall.Kill(-pgid, sig)
+}
+
+// run starts the command, tracks its process group until it has exited and waits for it.
+func (k *groupKiller) run() error {
+	err := k.cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	pgid := k.cmd.Process.Pid
+	liveGroups.Lock()
+	liveGroups.pgids[pgid] = struct{}{}
+	liveGroups.Unlock()
+
+	defer func() {
+		liveGroups.Lock()
+		delete(liveGroups.pgids, pgid)
+		liveGroups.Unlock()
+	}()
+
+	return k.cmd.Wait()
+}
+
+// stop cancels the pending SIGKILL once the command has exited.
+func (k *groupKiller) stop() {
+	if k.timer != nil {
+		k.timer.Stop()
+	}
+}
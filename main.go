@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/alexander-kolodka/crestic/cmd"
+	"github.com/alexander-kolodka/crestic/internal/shell"
 )
 
-const gracefulShutdownTimeout = time.Second * 1
+// cleanupTimeout is the time failure hooks and healthcheck pings get
+// after the grace period of interrupted commands has passed.
+const cleanupTimeout = time.Second * 30
 
 func main() {
 	os.Exit(execute())
@@ -20,9 +23,10 @@ func main() {
 func execute() int {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -37,7 +41,11 @@ func execute() int {
 		return 0
 
 	case sig := <-sigCh:
-		fmt.Fprintf(os.Stderr, "received %s, shutting down gracefully...\n", sig)
+		grace := cmd.GracePeriod()
+		fmt.Fprintf(os.Stderr, "received %s, waiting up to %s for running commands to finish...\n", sig, grace)
+
+		// Running commands receive the same signal and are killed after the grace period.
+		cancel(&shell.InterruptedError{Signal: sig})
 
 		select {
 		case err := <-errCh:
@@ -46,8 +54,15 @@ func execute() int {
 			}
 			return signalExitCode(sig)
 
-		case <-time.After(gracefulShutdownTimeout):
+		case sig = <-sigCh:
+			fmt.Fprintf(os.Stderr, "received %s again, exiting immediately\n", sig)
+			// Running commands are in their own process groups and would outlive crestic.
+			shell.KillAll()
+			return signalExitCode(sig)
+
+		case <-time.After(grace + cleanupTimeout):
 			fmt.Fprintln(os.Stderr, "graceful shutdown timed out")
+			shell.KillAll()
 			return signalExitCode(sig)
 		}
 	}